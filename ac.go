@@ -0,0 +1,117 @@
+package broadlink
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Operation mode of an air conditioner.
+type ACMode int
+
+const (
+	AC_MODE_AUTO ACMode = iota
+	AC_MODE_COOL
+	AC_MODE_HEAT
+	AC_MODE_DRY
+	AC_MODE_FAN // fan only
+)
+
+// Fan speed of an air conditioner.
+type ACFan int
+
+const (
+	AC_FAN_AUTO ACFan = iota
+	AC_FAN_LOW
+	AC_FAN_MEDIUM
+	AC_FAN_HIGH
+)
+
+// A full state of an air conditioner. AC remotes send the whole state in every frame.
+// Features a protocol does not have are ignored.
+type ACState struct {
+	Power       bool
+	Mode        ACMode
+	Temperature int // setpoint in Celsius
+	Fan         ACFan
+	Swing       bool // vertical swing
+	SwingH      bool // horizontal swing
+	Turbo       bool // powerful mode
+	Quiet       bool // quiet fan
+}
+
+// An air conditioner protocol encoder.
+type acProtocol struct {
+	name           string
+	minTemperature int
+	maxTemperature int
+
+	// render a state into pulses
+	encode func(st ACState) (pulses []int, err error)
+}
+
+var acProtocols = map[string]*acProtocol{}
+
+// Register an AC protocol encoder. Called from init() of each protocol implementation.
+func registerACProtocol(p *acProtocol) {
+	acProtocols[p.name] = p
+}
+
+// Get the names of supported AC protocols.
+func ACProtocols() (names []string) {
+	for n := range acProtocols {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Encode an AC state into a code for SendIRRemoteCode(). See ACProtocols() for protocol names.
+func EncodeACState(protocol string, st ACState) (code []byte, err error) {
+	p, ok := acProtocols[protocol]
+	if !ok {
+		err = fmt.Errorf("unknown AC protocol %s", protocol)
+		return
+	}
+	if st.Temperature < p.minTemperature || st.Temperature > p.maxTemperature {
+		err = fmt.Errorf("temperature %d out of range %d-%d", st.Temperature, p.minTemperature, p.maxTemperature)
+		return
+	}
+	if st.Mode < AC_MODE_AUTO || st.Mode > AC_MODE_FAN {
+		err = fmt.Errorf("invalid mode %d", st.Mode)
+		return
+	}
+	if st.Fan < AC_FAN_AUTO || st.Fan > AC_FAN_HIGH {
+		err = fmt.Errorf("invalid fan speed %d", st.Fan)
+		return
+	}
+	pulses, err := p.encode(st)
+	if err != nil {
+		return
+	}
+	code = EncodeCode(pulses)
+	return
+}
+
+// Send an AC state.
+func (d *Device) SendACState(protocol string, st ACState) (err error) {
+	code, err := EncodeACState(protocol, st)
+	if err != nil {
+		return
+	}
+	return d.SendIRRemoteCode(code, 1)
+}
+
+// append bytes in LSB first order
+func (pd *pulseDistance) bytes(b *pulseBuilder, data []byte) {
+	for _, v := range data {
+		pd.bits(b, uint64(v), 8)
+	}
+}
+
+// sum of bytes
+func sumBytes(data []byte) (sum byte) {
+	for _, v := range data {
+		sum += v
+	}
+	return
+}
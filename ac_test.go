@@ -0,0 +1,124 @@
+package broadlink
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestACProtocols(t *testing.T) {
+
+	// every protocol encodes every mode
+	for _, name := range ACProtocols() {
+		for mode := AC_MODE_AUTO; mode <= AC_MODE_FAN; mode++ {
+			for _, power := range []bool{true, false} {
+				st := ACState{Power: power, Mode: mode, Temperature: 24, Fan: AC_FAN_HIGH, Swing: true}
+				code, err := EncodeACState(name, st)
+				if err != nil {
+					t.Fatalf("%s %v: %v", name, st, err)
+				}
+				if _, err = DecodeCode(code); err != nil {
+					t.Fatalf("%s %v: %v", name, st, err)
+				}
+			}
+		}
+	}
+
+	if _, err := EncodeACState("Gree", ACState{Power: true, Temperature: 40}); err == nil {
+		t.Errorf("temperature out of range is accepted")
+	}
+	if _, err := EncodeACState("NoSuchAC", ACState{}); err == nil {
+		t.Errorf("unknown protocol is accepted")
+	}
+}
+
+func TestSamsungAC(t *testing.T) {
+
+	// known states of Samsung remotes
+	on := ACState{Power: true, Mode: AC_MODE_COOL, Temperature: 20}
+	if s := samsungACState(on); !bytes.Equal(s, []byte{0x02, 0x92, 0x0f, 0x00, 0x00, 0x00, 0xf0, 0x01, 0xe2, 0xfe, 0x71, 0x40, 0x11, 0xf0}) {
+		t.Errorf("unexpected state % x", s)
+	}
+	off := ACState{Mode: AC_MODE_COOL, Temperature: 24}
+	if s := samsungACState(off); !bytes.Equal(s, []byte{0x02, 0xb2, 0x0f, 0x00, 0x00, 0x00, 0xc0, 0x01, 0xd2, 0x0f, 0x00, 0x00, 0x00, 0x00, 0x01, 0x02, 0xff, 0x71, 0x80, 0x11, 0xc0}) {
+		t.Errorf("unexpected state % x", s)
+	}
+
+	// read the sections back from the pulses
+	code, err := EncodeACState("Samsung", on)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []byte
+	for i := 2; i < len(pulses); i += 2 + 56*2 + 2 {
+		if !samsungACTiming.matchHeader(pulses, i) {
+			t.Fatalf("no section header at %d", i)
+		}
+		for n := 0; n < 7; n++ {
+			v, _, ok := samsungACTiming.readBits(pulses, i+2+n*16, 8)
+			if !ok {
+				t.Fatalf("invalid bits at %d", i)
+			}
+			got = append(got, byte(v))
+		}
+	}
+	if !bytes.Equal(got, samsungACState(on)) {
+		t.Errorf("unexpected bytes % x", got)
+	}
+}
+
+func TestLGAC(t *testing.T) {
+	tests := []struct {
+		st  ACState
+		msg uint32
+	}{
+		{ACState{Power: true, Mode: AC_MODE_COOL, Temperature: 18, Fan: AC_FAN_HIGH}, 0x8800347},
+		{ACState{Mode: AC_MODE_COOL, Temperature: 18}, 0x88c0051},
+	}
+	for _, tc := range tests {
+		if msg := lgACMessage(tc.st); msg != tc.msg {
+			t.Errorf("%v: unexpected message %07x", tc.st, msg)
+		}
+	}
+}
+
+func TestACChecksums(t *testing.T) {
+	st := ACState{Power: true, Mode: AC_MODE_HEAT, Temperature: 23, Fan: AC_FAN_LOW, Turbo: true}
+
+	s := daikinState(st)
+	if len(s) != 35 || s[7] != sumBytes(s[:7]) || s[15] != sumBytes(s[8:15]) || s[34] != sumBytes(s[16:34]) || s[22] != 46 {
+		t.Errorf("unexpected Daikin state % x", s)
+	}
+	s = mitsubishiACState(st)
+	if len(s) != 18 || s[17] != sumBytes(s[:17]) || s[7] != 7 {
+		t.Errorf("unexpected Mitsubishi state % x", s)
+	}
+	s = fujitsuACState(st)
+	if len(s) != 16 || sumBytes(s[7:]) != 0 || s[8]>>4 != 7 {
+		t.Errorf("unexpected Fujitsu state % x", s)
+	}
+	s = greeState(st)
+	if len(s) != 8 || s[0] != 0x1c || s[1] != 7 || s[2] != 0x70 {
+		t.Errorf("unexpected Gree state % x", s)
+	}
+}
+
+func TestGreeAC(t *testing.T) {
+	tests := []struct {
+		st    ACState
+		state []byte
+	}{
+		// cool at 26°C with auto fan
+		{ACState{Power: true, Mode: AC_MODE_COOL, Temperature: 26}, []byte{0x09, 0x0a, 0x60, 0x50, 0x00, 0x20, 0x00, 0xf0}},
+		// the YAW1F model bit is cleared when off
+		{ACState{Mode: AC_MODE_COOL, Temperature: 26}, []byte{0x01, 0x0a, 0x20, 0x50, 0x00, 0x20, 0x00, 0x70}},
+	}
+	for _, tc := range tests {
+		if s := greeState(tc.st); !bytes.Equal(s, tc.state) {
+			t.Errorf("%+v: state % x, expected % x", tc.st, s, tc.state)
+		}
+	}
+}
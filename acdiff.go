@@ -0,0 +1,413 @@
+package broadlink
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A captured code labeled with its known state, e.g. "cool 24 auto".
+type LabeledCode struct {
+	Label string
+	Code  []byte
+}
+
+// Options of DiffCaptures().
+type DiffOptions struct {
+	MSBFirst bool // bit order to pack bits into bytes. Most AC protocols send LSB first
+}
+
+// A captured code decoded into bits.
+type CaptureBits struct {
+	Label    string
+	Sections []string // bits of each section in transmission order, as '0' and '1' characters
+	Bytes    [][]byte // bits of each section packed into bytes. A trailing partial byte is dropped
+}
+
+// Changed bits between two captures.
+type BitChange struct {
+	From, To string // labels of the captures
+	Bits     []int  // indexes of changed bits in the aligned bit stream
+}
+
+// A checksum scheme found to hold in all captures.
+type ChecksumGuess struct {
+	Section    int    // section index
+	Scheme     string // "sum", "negated sum", "xor", "nibble sum", "split nibble sum" or "crc8"
+	Start, End int    // the checksum covers bytes Start to End-1 of the section
+	Byte       int    // index of the checksum byte in the section
+	Nibble     string // "" if the checksum is the whole byte, or "high" or "low" for a 4-bit checksum in a nibble of the byte
+	Offset     byte   // constant added to a 4-bit checksum
+}
+
+// Result of DiffCaptures().
+type CaptureDiff struct {
+	Captures       []CaptureBits
+	SectionLengths []int           // aligned length of each section in bits, the longest of the captures
+	Varying        []int           // indexes of aligned bits which differ between any captures
+	Changes        []BitChange     // changes between consecutive captures
+	Checksums      []ChecksumGuess // checksum schemes holding in all captures of at least two different payloads
+}
+
+// Decode captured codes of an AC remote into bit streams and compare them.
+// Codes are split into sections at header marks and long spaces, and sections are aligned by their index. A section missing from a capture counts as different bits.
+// Checksums are searched at the last byte of each byte-aligned section, over ranges of bytes ending just before it. 4-bit checksums with a constant offset, such as Gree's, are searched at both nibbles of the byte.
+// A checksum is only reported if it holds in at least two captures with different bytes in the range, and checksums spanning sections are not found.
+func DiffCaptures(codes []LabeledCode, opt DiffOptions) (diff CaptureDiff, err error) {
+	if len(codes) == 0 {
+		err = fmt.Errorf("no captures")
+		return
+	}
+	for _, c := range codes {
+		pulses, e := DecodeCode(c.Code)
+		if e != nil {
+			err = fmt.Errorf("capture %s: %v", c.Label, e)
+			return
+		}
+		cb := CaptureBits{Label: c.Label, Sections: captureBits(pulses)}
+		if len(cb.Sections) == 0 {
+			err = fmt.Errorf("capture %s: no bits found", c.Label)
+			return
+		}
+		for _, s := range cb.Sections {
+			cb.Bytes = append(cb.Bytes, packBits(s, opt.MSBFirst))
+		}
+		diff.Captures = append(diff.Captures, cb)
+	}
+
+	// aligned streams
+	for _, cb := range diff.Captures {
+		for i, s := range cb.Sections {
+			if i >= len(diff.SectionLengths) {
+				diff.SectionLengths = append(diff.SectionLengths, 0)
+			}
+			if len(s) > diff.SectionLengths[i] {
+				diff.SectionLengths[i] = len(s)
+			}
+		}
+	}
+	streams := make([]string, len(diff.Captures))
+	for n, cb := range diff.Captures {
+		var sb strings.Builder
+		for i, l := range diff.SectionLengths {
+			s := ""
+			if i < len(cb.Sections) {
+				s = cb.Sections[i]
+			}
+			sb.WriteString(s + strings.Repeat("-", l-len(s))) // '-' for a missing bit
+		}
+		streams[n] = sb.String()
+	}
+
+	if len(streams) > 0 {
+		for i := 0; i < len(streams[0]); i++ {
+			for _, s := range streams[1:] {
+				if s[i] != streams[0][i] {
+					diff.Varying = append(diff.Varying, i)
+					break
+				}
+			}
+		}
+	}
+	for n := 1; n < len(streams); n++ {
+		ch := BitChange{From: diff.Captures[n-1].Label, To: diff.Captures[n].Label}
+		for i := range streams[n] {
+			if streams[n][i] != streams[n-1][i] {
+				ch.Bits = append(ch.Bits, i)
+			}
+		}
+		diff.Changes = append(diff.Changes, ch)
+	}
+
+	diff.Checksums = guessChecksums(diff.Captures)
+	return
+}
+
+// Decode pulses into bit sections. Bits are read from spaces of pulse distance coding, or from marks if all spaces are the same.
+func captureBits(pulses []int) (sections []string) {
+	var marks, spaces []int
+	for i, d := range pulses {
+		if i%2 == 0 {
+			marks = append(marks, d)
+		} else {
+			spaces = append(spaces, d)
+		}
+	}
+	bitMark := median(marks)
+
+	// the shortest values of bit marks and spaces
+	var bitMarks, bitSpaces []int
+	for i := 0; i+1 < len(pulses); i += 2 {
+		if pulses[i]*2 <= bitMark*5 {
+			bitMarks = append(bitMarks, pulses[i])
+			bitSpaces = append(bitSpaces, pulses[i+1])
+		}
+	}
+	shortMark, shortSpace := shortest(bitMarks), shortest(bitSpaces)
+	byMark := true
+	for _, s := range bitSpaces {
+		if s*2 > shortSpace*3 && s <= shortSpace*5 {
+			byMark = false
+			break
+		}
+	}
+
+	var cur []byte
+	flush := func() {
+		if len(cur) > 0 {
+			sections = append(sections, string(cur))
+			cur = nil
+		}
+	}
+	for i := 0; i < len(pulses); i += 2 {
+		mark, space := pulses[i], 0
+		if i+1 < len(pulses) {
+			space = pulses[i+1]
+		}
+		if mark*2 > bitMark*5 {
+			flush() // header mark
+			continue
+		}
+		if byMark {
+			switch {
+			case mark*2 <= shortMark*3:
+				cur = append(cur, '0')
+			case mark <= shortMark*5:
+				cur = append(cur, '1')
+			}
+			if space == 0 || space > shortSpace*5 {
+				flush()
+			}
+			continue
+		}
+		switch {
+		case space == 0 || space > shortSpace*5:
+			flush() // a footer mark and a gap
+		case space*2 <= shortSpace*3:
+			cur = append(cur, '0')
+		default:
+			cur = append(cur, '1')
+		}
+	}
+	flush()
+	return
+}
+
+// a short representative value, at the 10th percentile
+func shortest(v []int) int {
+	if len(v) == 0 {
+		return 0
+	}
+	s := append([]int{}, v...)
+	sort.Ints(s)
+	return s[len(s)/10]
+}
+
+// pack bits into bytes
+func packBits(bits string, msbFirst bool) (data []byte) {
+	for i := 0; i+8 <= len(bits); i += 8 {
+		var b byte
+		for n := 0; n < 8; n++ {
+			if bits[i+n] != '1' {
+				continue
+			}
+			if msbFirst {
+				b |= 0x80 >> uint(n)
+			} else {
+				b |= 1 << uint(n)
+			}
+		}
+		data = append(data, b)
+	}
+	return
+}
+
+// checksum schemes over bytes
+var checksumSchemes = []struct {
+	name string
+	calc func(data []byte) byte
+}{
+	{"sum", sumBytes},
+	{"negated sum", func(data []byte) byte { return -sumBytes(data) }},
+	{"xor", func(data []byte) (x byte) {
+		for _, v := range data {
+			x ^= v
+		}
+		return
+	}},
+	{"nibble sum", func(data []byte) (sum byte) {
+		for _, v := range data {
+			sum += v>>4 + v&0x0f
+		}
+		return
+	}},
+	{"split nibble sum", func(data []byte) (sum byte) { // low nibbles of the first half and high nibbles of the rest
+		h := (len(data) + 1) / 2
+		for i, v := range data {
+			if i < h {
+				sum += v & 0x0f
+			} else {
+				sum += v >> 4
+			}
+		}
+		return
+	}},
+	{"crc8", crc8},
+}
+
+// CRC-8 of polynomial 0x07
+func crc8(data []byte) (crc byte) {
+	for _, v := range data {
+		crc ^= v
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return
+}
+
+// find checksum schemes holding in all captures. For each section, scheme and position, the widest range is reported.
+func guessChecksums(captures []CaptureBits) (guesses []ChecksumGuess) {
+	for sec := 0; sec < maxSections(captures); sec++ {
+		// the section must have the same length in all captures
+		n, same := -1, true
+		for _, cb := range captures {
+			if sec >= len(cb.Bytes) || n >= 0 && len(cb.Bytes[sec]) != n {
+				same = false
+				break
+			}
+			n = len(cb.Bytes[sec])
+		}
+		if !same || n < 2 {
+			continue
+		}
+		last := n - 1
+		for _, scheme := range checksumSchemes {
+			for _, nibble := range []string{"", "high", "low"} {
+				found := false
+				for start := 0; start < last && !found; start++ {
+					var offset byte
+					if offset, found = checkChecksum(captures, sec, scheme.calc, start, last, nibble); found {
+						guesses = append(guesses, ChecksumGuess{Section: sec, Scheme: scheme.name, Start: start, End: last, Byte: last, Nibble: nibble, Offset: offset})
+					}
+				}
+				if found && nibble == "" {
+					break // a whole byte checksum holds in both nibbles as well
+				}
+			}
+		}
+	}
+	return
+}
+
+// check a checksum of bytes start to end-1 at byte end holds in all captures, and at least two captures differ in the range.
+// For a 4-bit checksum in a nibble, the difference from the calculated value must be the same in all captures, and is returned as the offset.
+func checkChecksum(captures []CaptureBits, sec int, calc func(data []byte) byte, start, end int, nibble string) (offset byte, ok bool) {
+	var first []byte
+	differ := false
+	for i, cb := range captures {
+		b := cb.Bytes[sec]
+		sum := calc(b[start:end])
+		var off byte
+		switch nibble {
+		case "":
+			if sum != b[end] {
+				return 0, false
+			}
+		case "high":
+			off = (b[end]>>4 - sum) & 0x0f
+		case "low":
+			off = (b[end] - sum) & 0x0f
+		}
+		if i == 0 {
+			offset, first = off, b[start:end]
+			continue
+		}
+		if off != offset {
+			return 0, false
+		}
+		if !bytes.Equal(first, b[start:end]) {
+			differ = true
+		}
+	}
+	return offset, differ
+}
+
+// maximum number of sections of captures
+func maxSections(captures []CaptureBits) (n int) {
+	for _, cb := range captures {
+		if len(cb.Bytes) > n {
+			n = len(cb.Bytes)
+		}
+	}
+	return
+}
+
+// A hex dump of captures with '^' marks under varying bytes, followed by changes and checksum guesses.
+func (d CaptureDiff) String() string {
+	var sb strings.Builder
+	width := 0
+	for _, cb := range d.Captures {
+		if len(cb.Label) > width {
+			width = len(cb.Label)
+		}
+	}
+	varying := map[int]bool{}
+	for _, i := range d.Varying {
+		varying[i] = true
+	}
+
+	for _, cb := range d.Captures {
+		fmt.Fprintf(&sb, "%-*s", width, cb.Label)
+		for _, b := range cb.Bytes {
+			sb.WriteString(" |")
+			for _, v := range b {
+				fmt.Fprintf(&sb, " %02x", v)
+			}
+		}
+		sb.WriteByte('\n')
+	}
+
+	// marks under varying bytes
+	fmt.Fprintf(&sb, "%-*s", width, "")
+	offset := 0
+	for _, l := range d.SectionLengths {
+		sb.WriteString("  ")
+		for i := 0; i+8 <= l; i += 8 {
+			mark := "  "
+			for n := 0; n < 8; n++ {
+				if varying[offset+i+n] {
+					mark = "^^"
+				}
+			}
+			sb.WriteString(" " + mark)
+		}
+		offset += l
+	}
+	sb.WriteByte('\n')
+
+	for _, ch := range d.Changes {
+		fmt.Fprintf(&sb, "%s -> %s: %d bits", ch.From, ch.To, len(ch.Bits))
+		if len(ch.Bits) > 0 {
+			s := make([]string, len(ch.Bits))
+			for i, b := range ch.Bits {
+				s[i] = fmt.Sprint(b)
+			}
+			fmt.Fprintf(&sb, " (%s)", strings.Join(s, " "))
+		}
+		sb.WriteByte('\n')
+	}
+	for _, g := range d.Checksums {
+		if g.Nibble != "" {
+			fmt.Fprintf(&sb, "section %d byte %d %s nibble: %s of bytes %d-%d plus %d\n", g.Section, g.Byte, g.Nibble, g.Scheme, g.Start, g.End-1, g.Offset)
+			continue
+		}
+		fmt.Fprintf(&sb, "section %d byte %d: %s of bytes %d-%d\n", g.Section, g.Byte, g.Scheme, g.Start, g.End-1)
+	}
+	return sb.String()
+}
@@ -0,0 +1,105 @@
+package broadlink
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffCaptures(t *testing.T) {
+
+	var codes []LabeledCode
+	for _, temp := range []int{24, 25, 25} {
+		st := ACState{Power: true, Mode: AC_MODE_COOL, Temperature: temp, Fan: AC_FAN_AUTO}
+		if len(codes) == 2 {
+			st.Fan = AC_FAN_HIGH
+		}
+		code, err := EncodeACState("Daikin", st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulses, err := DecodeCode(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// add some jitter like a captured code
+		for i := range pulses {
+			if i%2 == 0 {
+				pulses[i] += 60
+			} else {
+				pulses[i] -= 60
+			}
+		}
+		codes = append(codes, LabeledCode{Label: fmt.Sprintf("cool %d fan %d", st.Temperature, st.Fan), Code: EncodeCode(pulses)})
+	}
+
+	diff, err := DiffCaptures(codes, DiffOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// leader and 3 sections
+	if len(diff.SectionLengths) != 4 || diff.SectionLengths[0] != 5 || diff.SectionLengths[3] != 19*8 {
+		t.Fatalf("unexpected sections %v", diff.SectionLengths)
+	}
+	if b := diff.Captures[0].Bytes[3]; b[6] != 48 || b[18] != sumBytes(b[:18]) {
+		t.Errorf("unexpected bytes % x", b)
+	}
+
+	// temperature is byte 6 of the last section, and the fan is the upper nibble of byte 8
+	base := 5 + 64 + 64
+	want := map[int]bool{}
+	for _, i := range diff.Changes[0].Bits {
+		want[i] = true
+	}
+	if !want[base+6*8+1] {
+		t.Errorf("temperature change not found: %v", diff.Changes[0].Bits)
+	}
+	for _, i := range diff.Changes[1].Bits {
+		if i >= base+6*8 && i < base+7*8 {
+			t.Errorf("unexpected change of bit %d", i)
+		}
+	}
+
+	found := false
+	for _, g := range diff.Checksums {
+		if g.Section == 3 && g.Scheme == "sum" && g.Start == 0 && g.Byte == 18 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("checksum not found: %v", diff.Checksums)
+	}
+	if s := diff.String(); !strings.Contains(s, "section 3 byte 18: sum of bytes 0-17") {
+		t.Errorf("unexpected dump:\n%s", s)
+	}
+}
+
+func TestGuessChecksums(t *testing.T) {
+	states := []ACState{
+		{Power: true, Mode: AC_MODE_COOL, Temperature: 24},
+		{Power: true, Mode: AC_MODE_HEAT, Temperature: 27, Fan: AC_FAN_HIGH},
+		{Power: true, Mode: AC_MODE_DRY, Temperature: 19, Swing: true},
+		{Mode: AC_MODE_COOL, Temperature: 30, Turbo: true},
+	}
+	var captures []CaptureBits
+	for _, st := range states {
+		captures = append(captures, CaptureBits{Bytes: [][]byte{greeState(st)}})
+	}
+
+	// Gree checksum in the high nibble of byte 7
+	found := false
+	for _, g := range guessChecksums(captures) {
+		if g.Scheme == "split nibble sum" && g.Byte == 7 && g.Nibble == "high" && g.Start == 0 && g.Offset == 10 {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Gree checksum not found: %v", guessChecksums(captures))
+	}
+
+	// a single payload proves nothing
+	if g := guessChecksums([]CaptureBits{captures[0], captures[0]}); len(g) != 0 {
+		t.Errorf("checksums guessed from one payload: %v", g)
+	}
+}
@@ -0,0 +1,337 @@
+package broadlink
+
+// Air conditioner protocols.
+//
+//	"Daikin"     Daikin ARC433 remotes. 35 bytes in 3 sections after a 5-bit leader. 10-32°C
+//	"Mitsubishi" Mitsubishi Electric. 18 bytes sent twice. 16-31°C. Turbo is not supported
+//	"Gree"       Gree YAW1F remotes. 8 bytes in 2 blocks. 16-30°C. Quiet and horizontal swing are not supported
+//	"LG"         LG 28-bit. 16-30°C. Swing and turbo are separate commands of LG remotes and not supported
+//	"Samsung"    Samsung 14-byte state, or 21 bytes to turn off. 16-30°C. Quiet is not supported
+//	"Fujitsu"    Fujitsu ARRAH2E remotes. 16 bytes, or 7 bytes to turn off. 16-30°C. Turbo is not supported
+
+var daikinTiming = pulseDistance{
+	headerMark: 3650, headerSpace: 1623,
+	bitMark:   428,
+	zeroSpace: 428, oneSpace: 1280,
+}
+
+var mitsubishiACTiming = pulseDistance{
+	headerMark: 3400, headerSpace: 1750,
+	bitMark:   450,
+	zeroSpace: 420, oneSpace: 1300,
+}
+
+var greeTiming = pulseDistance{
+	headerMark: 9000, headerSpace: 4500,
+	bitMark:   620,
+	zeroSpace: 540, oneSpace: 1600,
+}
+
+var lgACTiming = pulseDistance{
+	headerMark: 8500, headerSpace: 4250,
+	bitMark:   550,
+	zeroSpace: 550, oneSpace: 1600,
+	msbFirst: true,
+}
+
+var samsungACTiming = pulseDistance{
+	headerMark: 3086, headerSpace: 8864, // section header
+	bitMark:   586,
+	zeroSpace: 436, oneSpace: 1432,
+}
+
+var fujitsuACTiming = pulseDistance{
+	headerMark: 3324, headerSpace: 1574,
+	bitMark:   448,
+	zeroSpace: 390, oneSpace: 1182,
+}
+
+const (
+	daikinGap            = 29000
+	mitsubishiACGap      = 17100
+	greeBlockSpace       = 19980
+	greeGap              = 40000
+	lgACGap              = 39750
+	samsungACHeaderMark  = 690
+	samsungACHeaderSpace = 17844
+	samsungACSectionGap  = 2886
+	fujitsuACGap         = 8100
+)
+
+func init() {
+	registerACProtocol(&acProtocol{name: "Daikin", minTemperature: 10, maxTemperature: 32, encode: encodeDaikin})
+	registerACProtocol(&acProtocol{name: "Mitsubishi", minTemperature: 16, maxTemperature: 31, encode: encodeMitsubishiAC})
+	registerACProtocol(&acProtocol{name: "Gree", minTemperature: 16, maxTemperature: 30, encode: encodeGree})
+	registerACProtocol(&acProtocol{name: "LG", minTemperature: 16, maxTemperature: 30, encode: encodeLGAC})
+	registerACProtocol(&acProtocol{name: "Samsung", minTemperature: 16, maxTemperature: 30, encode: encodeSamsungAC})
+	registerACProtocol(&acProtocol{name: "Fujitsu", minTemperature: 16, maxTemperature: 30, encode: encodeFujitsuAC})
+}
+
+// Daikin state bytes
+func daikinState(st ACState) []byte {
+	s := []byte{
+		0x11, 0xda, 0x27, 0x00, 0xc5, 0x00, 0x00, 0x00,
+		0x11, 0xda, 0x27, 0x00, 0x42, 0x00, 0x00, 0x00,
+		0x11, 0xda, 0x27, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x06, 0x60, 0x00, 0x00, 0xc0, 0x00, 0x00, 0x00,
+	}
+	mode := map[ACMode]byte{AC_MODE_AUTO: 0, AC_MODE_COOL: 3, AC_MODE_HEAT: 4, AC_MODE_DRY: 2, AC_MODE_FAN: 6}[st.Mode]
+	s[21] = mode<<4 | 0x08
+	if st.Power {
+		s[21] |= 0x01
+	}
+	s[22] = byte(st.Temperature * 2)
+	fan := map[ACFan]byte{AC_FAN_AUTO: 0xa, AC_FAN_LOW: 3, AC_FAN_MEDIUM: 5, AC_FAN_HIGH: 7}[st.Fan]
+	if st.Quiet {
+		fan = 0xb
+	}
+	s[24] = fan << 4
+	if st.Swing {
+		s[24] |= 0x0f
+	}
+	if st.SwingH {
+		s[25] = 0x0f
+	}
+	if st.Turbo {
+		s[29] |= 0x01
+	}
+	s[7] = sumBytes(s[0:7])
+	s[15] = sumBytes(s[8:15])
+	s[34] = sumBytes(s[16:34])
+	return s
+}
+
+func encodeDaikin(st ACState) (pulses []int, err error) {
+	s := daikinState(st)
+	var b pulseBuilder
+	daikinTiming.bits(&b, 0, 5) // leader
+	b.mark(daikinTiming.bitMark)
+	b.space(daikinTiming.zeroSpace + daikinGap)
+	for _, section := range [][]byte{s[0:8], s[8:16], s[16:]} {
+		daikinTiming.header(&b)
+		daikinTiming.bytes(&b, section)
+		b.mark(daikinTiming.bitMark)
+		b.space(daikinTiming.zeroSpace + daikinGap)
+	}
+	pulses = b.pulses
+	return
+}
+
+// Mitsubishi Electric state bytes
+func mitsubishiACState(st ACState) []byte {
+	s := []byte{0x23, 0xcb, 0x26, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}
+	if st.Power {
+		s[5] = 0x20
+	}
+	s[6] = map[ACMode]byte{AC_MODE_AUTO: 0x20, AC_MODE_COOL: 0x18, AC_MODE_HEAT: 0x08, AC_MODE_DRY: 0x10, AC_MODE_FAN: 0x38}[st.Mode]
+	s[7] = byte(st.Temperature - 16)
+	s[8] = map[ACMode]byte{AC_MODE_AUTO: 0x30, AC_MODE_COOL: 0x36, AC_MODE_HEAT: 0x30, AC_MODE_DRY: 0x32, AC_MODE_FAN: 0x37}[st.Mode]
+	switch {
+	case st.Quiet:
+		s[9] = 6
+	case st.Fan == AC_FAN_AUTO:
+		s[9] = 0x80
+	default:
+		s[9] = map[ACFan]byte{AC_FAN_LOW: 1, AC_FAN_MEDIUM: 3, AC_FAN_HIGH: 5}[st.Fan]
+	}
+	if st.Swing {
+		s[9] |= 0x40 | 7<<3
+	}
+	s[17] = sumBytes(s[:17])
+	return s
+}
+
+func encodeMitsubishiAC(st ACState) (pulses []int, err error) {
+	s := mitsubishiACState(st)
+	var b pulseBuilder
+	for i := 0; i < 2; i++ {
+		mitsubishiACTiming.header(&b)
+		mitsubishiACTiming.bytes(&b, s)
+		b.mark(mitsubishiACTiming.bitMark)
+		b.space(mitsubishiACGap)
+	}
+	pulses = b.pulses
+	return
+}
+
+// Gree state bytes
+func greeState(st ACState) []byte {
+	s := []byte{0x00, 0x00, 0x20, 0x50, 0x00, 0x20, 0x00, 0x00}
+	s[0] = map[ACMode]byte{AC_MODE_AUTO: 0, AC_MODE_COOL: 1, AC_MODE_DRY: 2, AC_MODE_FAN: 3, AC_MODE_HEAT: 4}[st.Mode]
+	if st.Power {
+		s[0] |= 0x08
+		s[2] |= 0x40 // YAW1F model bit, set only while the power is on
+	}
+	s[0] |= byte(st.Fan) << 4 // auto, low, medium and high are 0 to 3
+	if st.Swing {
+		s[0] |= 0x40
+		s[4] = 0x01 // swing over the full range
+	}
+	s[1] = byte(st.Temperature - 16)
+	if st.Turbo {
+		s[2] |= 0x10
+	}
+
+	// checksum is the upper nibble of the last byte
+	sum := byte(10)
+	for _, v := range s[:4] {
+		sum += v & 0x0f
+	}
+	for _, v := range s[4:7] {
+		sum += v >> 4
+	}
+	s[7] = sum<<4 | s[7]&0x0f
+	return s
+}
+
+func encodeGree(st ACState) (pulses []int, err error) {
+	s := greeState(st)
+	var b pulseBuilder
+	greeTiming.header(&b)
+	greeTiming.bytes(&b, s[:4])
+	greeTiming.bits(&b, 0x2, 3) // block footer 0b010
+	b.mark(greeTiming.bitMark)
+	b.space(greeBlockSpace)
+	greeTiming.bytes(&b, s[4:])
+	b.mark(greeTiming.bitMark)
+	b.space(greeGap)
+	pulses = b.pulses
+	return
+}
+
+// LG 28-bit message
+func lgACMessage(st ACState) uint32 {
+	if !st.Power {
+		return 0x88c0051
+	}
+	msg := uint32(0x88) << 20
+	msg |= uint32(map[ACMode]byte{AC_MODE_COOL: 0, AC_MODE_DRY: 1, AC_MODE_FAN: 2, AC_MODE_AUTO: 3, AC_MODE_HEAT: 4}[st.Mode]) << 12
+	msg |= uint32(st.Temperature-15) << 8
+	fan := map[ACFan]uint32{AC_FAN_AUTO: 5, AC_FAN_LOW: 1, AC_FAN_MEDIUM: 2, AC_FAN_HIGH: 4}[st.Fan]
+	if st.Quiet {
+		fan = 0
+	}
+	msg |= fan << 4
+
+	// checksum is the sum of the 4 nibbles before it
+	sum := uint32(0)
+	for i := uint(4); i < 20; i += 4 {
+		sum += msg >> i & 0xf
+	}
+	return msg | sum&0xf
+}
+
+func encodeLGAC(st ACState) (pulses []int, err error) {
+	var b pulseBuilder
+	lgACTiming.header(&b)
+	lgACTiming.bits(&b, uint64(lgACMessage(st)), 28)
+	b.mark(lgACTiming.bitMark)
+	b.space(lgACGap)
+	pulses = b.pulses
+	return
+}
+
+// Samsung state bytes of 7-byte sections
+func samsungACState(st ACState) []byte {
+	s := []byte{0x02, 0x92, 0x0f, 0x00, 0x00, 0x00, 0xf0, 0x01, 0xe2, 0xfe, 0x71, 0x40, 0x11, 0xf0}
+	s[11] = byte(st.Temperature-16)<<4 | s[11]&0x0f
+
+	mode := map[ACMode]byte{AC_MODE_AUTO: 0, AC_MODE_COOL: 1, AC_MODE_DRY: 2, AC_MODE_FAN: 3, AC_MODE_HEAT: 4}[st.Mode]
+	fan := map[ACFan]byte{AC_FAN_AUTO: 0, AC_FAN_LOW: 2, AC_FAN_MEDIUM: 4, AC_FAN_HIGH: 5}[st.Fan]
+	switch {
+	case st.Mode == AC_MODE_AUTO:
+		fan = 6 // auto mode has its own fan setting
+	case st.Turbo:
+		fan = 7
+	}
+	s[12] = s[12]&0x81 | mode<<4 | fan<<1
+
+	swing := byte(7) // off
+	switch {
+	case st.Swing && st.SwingH:
+		swing = 4
+	case st.Swing:
+		swing = 2
+	case st.SwingH:
+		swing = 3
+	}
+	s[9] = s[9]&0x8f | swing<<4
+
+	if !st.Power {
+		s[6] &^= 0x30
+		s[13] &^= 0x30
+		// turning off needs an extended message with an extra section
+		s = append(append(append([]byte{}, s[:7]...), 0x01, 0xd2, 0x0f, 0x00, 0x00, 0x00, 0x00), s[7:]...)
+	}
+	for i := 0; i < len(s); i += 7 {
+		samsungACChecksum(s[i : i+7])
+	}
+	return s
+}
+
+// set the checksum of a Samsung section. It is the inverted count of set bits, stored in the upper nibble of byte 1 and the lower nibble of byte 2.
+func samsungACChecksum(section []byte) {
+	count := func(v byte) (n byte) {
+		for ; v != 0; v &= v - 1 {
+			n++
+		}
+		return
+	}
+	sum := count(section[0]) + count(section[1]&0x0f) + count(section[2]>>4)
+	for _, v := range section[3:7] {
+		sum += count(v)
+	}
+	sum ^= 0xff
+	section[1] = section[1]&0x0f | sum<<4
+	section[2] = section[2]&0xf0 | sum>>4
+}
+
+func encodeSamsungAC(st ACState) (pulses []int, err error) {
+	s := samsungACState(st)
+	var b pulseBuilder
+	b.mark(samsungACHeaderMark)
+	b.space(samsungACHeaderSpace)
+	for i := 0; i < len(s); i += 7 {
+		samsungACTiming.header(&b)
+		samsungACTiming.bytes(&b, s[i:i+7])
+		b.mark(samsungACTiming.bitMark)
+		if i+7 < len(s) {
+			b.space(samsungACSectionGap)
+		} else {
+			b.space(defaultTrailingGap)
+		}
+	}
+	pulses = b.pulses
+	return
+}
+
+// Fujitsu state bytes
+func fujitsuACState(st ACState) []byte {
+	if !st.Power {
+		return []byte{0x14, 0x63, 0x00, 0x10, 0x10, 0x02, 0xfd}
+	}
+	s := []byte{0x14, 0x63, 0x00, 0x10, 0x10, 0xfe, 0x09, 0x30, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x20, 0x00}
+	s[8] = byte(st.Temperature-16)<<4 | 0x01
+	s[9] = map[ACMode]byte{AC_MODE_AUTO: 0, AC_MODE_COOL: 1, AC_MODE_DRY: 2, AC_MODE_FAN: 3, AC_MODE_HEAT: 4}[st.Mode]
+	s[10] = map[ACFan]byte{AC_FAN_AUTO: 0, AC_FAN_HIGH: 1, AC_FAN_MEDIUM: 2, AC_FAN_LOW: 3}[st.Fan]
+	if st.Quiet {
+		s[10] = 4
+	}
+	if st.Swing {
+		s[10] |= 0x10
+	}
+	if st.SwingH {
+		s[10] |= 0x20
+	}
+	s[15] = -sumBytes(s[7:15])
+	return s
+}
+
+func encodeFujitsuAC(st ACState) (pulses []int, err error) {
+	var b pulseBuilder
+	fujitsuACTiming.header(&b)
+	fujitsuACTiming.bytes(&b, fujitsuACState(st))
+	b.mark(fujitsuACTiming.bitMark)
+	b.space(fujitsuACGap)
+	pulses = b.pulses
+	return
+}
@@ -0,0 +1,102 @@
+package broadlink
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// Fixed IR carrier frequency of BroadLink RM devices in Hz.
+	BroadLinkCarrierFrequency = 38000
+
+	// Relative difference of carrier frequencies that receivers usually tolerate
+	carrierTolerance = 0.15
+)
+
+var (
+	ErrUnsupportedCarrier = fmt.Errorf("carrier frequency not supported by BroadLink devices") // The signal is modulated at a carrier the RM hardware cannot emit
+)
+
+// Check a carrier frequency in Hz can be emitted by a BroadLink device.
+// BroadLink RM devices always emit 38kHz, which receivers of about 32kHz to 44kHz accept. A zero frequency is taken as unknown and passes.
+func CheckCarrier(freq int) (err error) {
+	if freq == 0 {
+		return
+	}
+	if math.Abs(float64(freq-BroadLinkCarrierFrequency)) > BroadLinkCarrierFrequency*carrierTolerance {
+		err = fmt.Errorf("%w: %dHz", ErrUnsupportedCarrier, freq)
+	}
+	return
+}
+
+// Check the carrier of an IR signal can be emitted by a BroadLink device. See CheckCarrier().
+// Call this before sending a converted signal, since the device sends it at 38kHz anyway.
+func (s *Signal) CheckCarrier() (err error) {
+	if s.Type != REMOTE_IR {
+		return
+	}
+	return CheckCarrier(s.Frequency)
+}
+
+// Convert a Pronto Hex code into an IR signal with its carrier frequency. See ProntoToCode() for repeats.
+// The carrier is not checked here. Call s.CheckCarrier() before sending the signal with a BroadLink device.
+func ProntoToSignal(pronto string, repeats int) (s *Signal, err error) {
+	freq, _, _, err := DecodePronto(pronto)
+	if err != nil {
+		return
+	}
+	code, err := ProntoToCode(pronto, repeats)
+	if err != nil {
+		return
+	}
+	if s, err = NewSignal(REMOTE_IR, code); err != nil {
+		return
+	}
+	s.Frequency = freq
+	return
+}
+
+// Convert the signal into a Pronto Hex code at its carrier frequency. If the frequency is unknown, 38kHz is assumed.
+// A signal sent once becomes the once sequence. A repeated signal becomes the repeat sequence, which Pronto players send as long as the button is held.
+// Pronto codes have no repeat count, so s.Repeat itself is not kept. Pass it to ProntoToSignal() to restore it.
+func (s *Signal) Pronto() (pronto string, err error) {
+	if s.Repeat > 0 {
+		return EncodePronto(s.Frequency, nil, s.Pulses)
+	}
+	return EncodePronto(s.Frequency, s.Pulses, nil)
+}
+
+// Convert a Global Caché sendir command into an IR signal with its carrier frequency.
+// The repeat count of the command is stored as the repeat of the signal. See ParseGlobalCache().
+// The carrier is not checked here. Call s.CheckCarrier() before sending the signal with a BroadLink device.
+func GlobalCacheToSignal(sendir string) (s *Signal, err error) {
+	code, count, freq, err := ParseGlobalCache(sendir)
+	if err != nil {
+		return
+	}
+	if s, err = NewSignal(REMOTE_IR, code); err != nil {
+		return
+	}
+	s.Repeat, s.Frequency = count-1, freq
+	return
+}
+
+// Convert the signal into a Global Caché sendir command at its carrier frequency. If the frequency is unknown, 38kHz is assumed.
+// id is the command ID.
+func (s *Signal) GlobalCache(id int) (sendir string, err error) {
+	return FormatGlobalCache(s.Code(), s.Repeat+1, s.Frequency, id)
+}
+
+// Encode an IR command into a signal with the carrier frequency of the protocol. See EncodeIRCommand().
+// The carrier is not checked here. Call s.CheckCarrier() before sending the signal with a BroadLink device.
+func EncodeIRSignal(c IRCommand, repeats int) (s *Signal, err error) {
+	code, err := EncodeIRCommand(c, repeats)
+	if err != nil {
+		return
+	}
+	if s, err = NewSignal(REMOTE_IR, code); err != nil {
+		return
+	}
+	s.Frequency = irProtocols[c.Protocol].frequency
+	return
+}
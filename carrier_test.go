@@ -0,0 +1,94 @@
+package broadlink
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCarrier(t *testing.T) {
+
+	for _, tc := range []struct {
+		freq int
+		ok   bool
+	}{{0, true}, {36000, true}, {38000, true}, {40000, true}, {56000, false}, {455000, false}} {
+		err := CheckCarrier(tc.freq)
+		if (err == nil) != tc.ok || err != nil && !errors.Is(err, ErrUnsupportedCarrier) {
+			t.Errorf("%d: unexpected result %v", tc.freq, err)
+		}
+	}
+
+	// Pronto carrier is preserved through conversions
+	once := []int{9000, 4500, 560, 1690, 560, 40000}
+	pronto, err := EncodePronto(56000, once, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := ProntoToSignal(pronto, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Frequency < 55000 || s.Frequency > 57000 || s.CheckCarrier() == nil {
+		t.Errorf("unexpected frequency %d", s.Frequency)
+	}
+	sendir, err := s.GlobalCache(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := GlobalCacheToSignal(sendir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(g.CheckCarrier(), ErrUnsupportedCarrier) {
+		t.Errorf("56kHz carrier is not detected")
+	}
+	if g.Frequency != s.Frequency || g.Repeat != 0 || len(g.Pulses) != len(once) {
+		t.Errorf("unexpected signal %+v", g)
+	}
+	p, err := g.Pronto()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Fields(p)[1] != strings.Fields(pronto)[1] {
+		t.Errorf("frequency word changed: %s / %s", p, pronto)
+	}
+
+	// a repeated signal becomes the repeat sequence
+	g.Repeat = 2
+	if p, err = g.Pronto(); err != nil {
+		t.Fatal(err)
+	}
+	if w := strings.Fields(p); w[2] != "0000" || w[3] != strings.Fields(pronto)[2] {
+		t.Errorf("repeat sequence not used: %s", p)
+	}
+	r, err := ProntoToSignal(p, g.Repeat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Pulses) != len(once)*2 {
+		t.Errorf("unexpected pulses %v", r.Pulses)
+	}
+
+	// RF signals have no carrier
+	rf := &Signal{Type: REMOTE_RF433Mhz, Frequency: 433920000}
+	if rf.CheckCarrier() != nil {
+		t.Errorf("RF signal is checked for a carrier")
+	}
+
+	sig, err := EncodeIRSignal(IRCommand{Protocol: "SIRC", Address: 1, Command: 21}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.Frequency != 40000 || sig.CheckCarrier() != nil {
+		t.Errorf("unexpected SIRC frequency %d", sig.Frequency)
+	}
+
+	// codes out of the carrier range are not sent
+	var d Device
+	if err := d.sendIRCode(sig.Code(), 56000); !errors.Is(err, ErrUnsupportedCarrier) {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := d.sendIRCode(sig.Code(), 40000); err == nil || errors.Is(err, ErrUnsupportedCarrier) {
+		t.Errorf("unexpected error %v", err) // fails for the missing MAC address
+	}
+}
@@ -0,0 +1,97 @@
+package broadlink
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Prefix of base64 code strings used by Home Assistant's remote.send_command service.
+const Base64CodePrefix = "b64:"
+
+// Format a code as a base64 string compatible with python-broadlink and Home Assistant.
+// rtype is the remote signal type. repeat is the repeat count stored in the code: 0 for once, 1 for twice, ...
+// The string has no "b64:" prefix. Prepend Base64CodePrefix to use it with remote.send_command.
+func FormatBase64Code(rtype RemoteType, repeat int, code []byte) string {
+	return base64.StdEncoding.EncodeToString(codePacket(rtype, repeat, code))
+}
+
+// Format a code as a hex string compatible with python-broadlink.
+func FormatHexCode(rtype RemoteType, repeat int, code []byte) string {
+	return hex.EncodeToString(codePacket(rtype, repeat, code))
+}
+
+// Parse a python-broadlink or Home Assistant code string into a remote type, repeat count and code for SendRemoteControlCode().
+// The string may be base64 with or without the "b64:" prefix, or hex.
+// Note that the repeat count is zero-based while the count of SendRemoteControlCode() is one-based.
+func ParseCodeString(s string) (rtype RemoteType, repeat int, code []byte, err error) {
+	s = strings.TrimSpace(s)
+
+	var data []byte
+	switch {
+	case strings.HasPrefix(s, Base64CodePrefix):
+		data, err = decodeBase64(s[len(Base64CodePrefix):])
+	case isHexCode(s):
+		data, err = hex.DecodeString(s)
+	default:
+		data, err = decodeBase64(s)
+	}
+	if err != nil {
+		return
+	}
+	return parseCodePacket(data)
+}
+
+// parse a code packet with the type, repeat and length header
+func parseCodePacket(data []byte) (rtype RemoteType, repeat int, code []byte, err error) {
+	if len(data) < 4 {
+		err = fmt.Errorf("incomplete data")
+		return
+	}
+	rtype, repeat = RemoteType(data[0]), int(data[1])
+	switch rtype {
+	case REMOTE_IR, REMOTE_RF433Mhz, REMOTE_RF315Mhz:
+	default:
+		err = fmt.Errorf("unknown remote type %02x", data[0])
+		return
+	}
+	sz := int(binary.LittleEndian.Uint16(data[2:4]))
+	if len(data) < 4+sz {
+		err = fmt.Errorf("incomplete data")
+		return
+	}
+	code = data[4 : 4+sz] // trailing zero padding is dropped
+	return
+}
+
+// decode a base64 string with or without padding
+func decodeBase64(s string) (data []byte, err error) {
+	data, err = base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(s, "="))
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid base64 code")
+	}
+	return
+}
+
+// check a string is a hex code of a known remote type.
+// Base64 code strings never qualify since their first characters are not hex digits.
+func isHexCode(s string) bool {
+	if len(s) < 8 || len(s)%2 != 0 {
+		return false
+	}
+	for _, c := range s {
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	switch strings.ToLower(s[:2]) {
+	case "26", "b2", "d7":
+		return true
+	}
+	return false
+}
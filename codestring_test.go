@@ -0,0 +1,43 @@
+package broadlink
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestCodeString(t *testing.T) {
+
+	// a learned code as stored by Home Assistant, with zero padding
+	src := "JgAcAB0dHB44HhweGx4cHR06HB0cHhwdHB8bHhwADQUAAAAAAAAAAAAAAAA="
+
+	for _, s := range []string{src, Base64CodePrefix + src} {
+		rtype, repeat, code, err := ParseCodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rtype != REMOTE_IR || repeat != 0 || len(code) != 0x1c {
+			t.Fatalf("invalid code: type %x, repeat %d, length %d", rtype, repeat, len(code))
+		}
+		if !bytes.Equal(code[len(code)-3:], []byte{0x00, 0x0d, 0x05}) {
+			t.Fatalf("invalid code end %x", code)
+		}
+
+		b64 := FormatBase64Code(rtype, repeat, code)
+		if b64 != "JgAcAB0dHB44HhweGx4cHR06HB0cHhwdHB8bHhwADQU=" {
+			t.Fatalf("invalid base64 code %s", b64)
+		}
+
+		hexcode := FormatHexCode(REMOTE_RF433Mhz, 3, code)
+		rtype, repeat, code2, err := ParseCodeString(hexcode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rtype != REMOTE_RF433Mhz || repeat != 3 || !bytes.Equal(code, code2) {
+			t.Fatalf("hex round trip failed: %s", hexcode)
+		}
+	}
+
+	if _, _, _, err := ParseCodeString("AQIDBA=="); err == nil {
+		t.Error("unknown remote type accepted")
+	}
+}
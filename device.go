@@ -26,13 +26,17 @@ type Device struct {
 	MACAddr []byte      // MAC address of the device
 	UDPAddr net.UDPAddr // IP address of the device
 
-	LocalAddr net.UDPAddr   // Local machine's IP address and port
-	Timeout   time.Duration // timeout for a command call
+	LocalAddr     net.UDPAddr   // Local machine's IP address and port
+	Timeout       time.Duration // timeout for a command call
+	PollInterval  time.Duration // interval to poll captured codes in LearnCode()
+	RearmInterval time.Duration // fixed period to re-enter capture mode in LearnCode(). Zero for 20 seconds, negative for never
 
 	ID uint32 // Local machine's ID returned on Auth command
 
 	counter uint16 // packet counter
 
+	irToggle map[string]bool // toggle bit states of IR protocols
+
 	aesKey   []byte // Key for data encryption
 	aesIV    []byte // IV for data encryption
 	aesBlock cipher.Block
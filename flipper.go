@@ -0,0 +1,280 @@
+package broadlink
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A signal of a Flipper Zero infrared file (.ir).
+type FlipperSignal struct {
+	Name      string  // button name, e.g. "Power"
+	Code      []byte  // BroadLink IR code for SendIRRemoteCode()
+	Frequency int     // carrier frequency in Hz. zero if not given
+	DutyCycle float64 // carrier duty cycle from 0 to 1. zero if not given
+}
+
+const (
+	flipperFileType       = "IR signals file"
+	flipperLibraryType    = "IR library file"
+	defaultFlipperDuty    = 0.33
+	flipperRC5CommandBits = 6
+)
+
+// Flipper protocol names and the registered IR protocols for them.
+var flipperProtocols = map[string]string{
+	"NEC":       "NEC",
+	"NECext":    "NECext",
+	"Samsung32": "Samsung32",
+	"RC5":       "RC5",
+	"RC5X":      "RC5",
+	"RC6":       "RC6",
+	"SIRC":      "SIRC",
+	"SIRC15":    "SIRC15",
+	"SIRC20":    "SIRC20",
+}
+
+// Flipper signal entry being parsed.
+type flipperEntry struct {
+	name, typ, protocol string
+	address, command    string
+	frequency           int
+	dutyCycle           float64
+	data                []int
+}
+
+// Read a Flipper Zero infrared file (.ir) and convert each signal to a BroadLink IR code.
+// Both parsed signals of protocols NEC, NECext, Samsung32, RC5, RC5X, RC6, SIRC, SIRC15 and SIRC20, and raw signals are supported.
+// Carriers are not checked. Call CheckCarrier() with the frequency of a signal before sending it with a BroadLink device.
+func ReadFlipperIR(r io.Reader) (signals []FlipperSignal, err error) {
+
+	var entry *flipperEntry
+	flush := func() error {
+		if entry == nil {
+			return nil
+		}
+		s, e := entry.build()
+		if e != nil {
+			return fmt.Errorf("signal %s: %v", entry.name, e)
+		}
+		signals = append(signals, s)
+		entry = nil
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // raw data lines may be long
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		i := strings.IndexByte(line, ':')
+		if i < 0 {
+			err = fmt.Errorf("line %d: missing ':'", lineNo)
+			return
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "Filetype":
+			if value != flipperFileType && value != flipperLibraryType {
+				err = fmt.Errorf("line %d: not an infrared file: %s", lineNo, value)
+				return
+			}
+			continue
+		case "Version":
+			continue
+		case "name":
+			if err = flush(); err != nil {
+				return
+			}
+			entry = &flipperEntry{name: value}
+			continue
+		}
+		if entry == nil {
+			err = fmt.Errorf("line %d: %s outside of a signal", lineNo, key)
+			return
+		}
+
+		switch key {
+		case "type":
+			entry.typ = value
+		case "protocol":
+			entry.protocol = value
+		case "address":
+			entry.address = value
+		case "command":
+			entry.command = value
+		case "frequency":
+			if entry.frequency, err = strconv.Atoi(value); err != nil {
+				err = fmt.Errorf("line %d: invalid frequency %s", lineNo, value)
+				return
+			}
+		case "duty_cycle":
+			if entry.dutyCycle, err = strconv.ParseFloat(value, 64); err != nil {
+				err = fmt.Errorf("line %d: invalid duty cycle %s", lineNo, value)
+				return
+			}
+		case "data":
+			// long raw signals may be split into several data lines
+			for _, f := range strings.Fields(value) {
+				v, e := strconv.Atoi(f)
+				if e != nil || v <= 0 {
+					err = fmt.Errorf("line %d: invalid duration %s", lineNo, f)
+					return
+				}
+				entry.data = append(entry.data, v)
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	err = flush()
+	return
+}
+
+// build a BroadLink code of a Flipper signal
+func (e *flipperEntry) build() (s FlipperSignal, err error) {
+	s.Name, s.Frequency, s.DutyCycle = e.name, e.frequency, e.dutyCycle
+	switch e.typ {
+	case "raw":
+		if len(e.data) == 0 {
+			err = fmt.Errorf("no raw data")
+			return
+		}
+		s.Code = EncodeCode(e.data)
+
+	case "parsed":
+		name, ok := flipperProtocols[e.protocol]
+		if !ok {
+			err = fmt.Errorf("unsupported protocol %s", e.protocol)
+			return
+		}
+		c := IRCommand{Protocol: name}
+		if c.Address, err = flipperValue(e.address); err != nil {
+			return
+		}
+		if c.Command, err = flipperValue(e.command); err != nil {
+			return
+		}
+		if name == necNameExtended {
+			// Flipper NECext commands are 16 bits sent as is, not an 8-bit command and its inverse
+			if c.Address > 0xffff || c.Command > 0xffff {
+				err = fmt.Errorf("NECext address and command must be 16 bits")
+				return
+			}
+			s.Code = EncodeCode(necPulses(uint64(c.Address)|uint64(c.Command)<<16, 0, false))
+		} else if s.Code, err = EncodeIRCommand(c, 0); err != nil {
+			return
+		}
+		s.Frequency = irProtocols[name].frequency
+
+	default:
+		err = fmt.Errorf("unknown signal type %q", e.typ)
+	}
+	return
+}
+
+// parse a Flipper value of little-endian hex bytes, e.g. "04 00 00 00"
+func flipperValue(s string) (v uint32, err error) {
+	b, err := hex.DecodeString(strings.Join(strings.Fields(s), ""))
+	if err != nil || len(b) > 4 {
+		err = fmt.Errorf("invalid value %q", s)
+		return
+	}
+	var buf [4]byte
+	copy(buf[:], b)
+	v = binary.LittleEndian.Uint32(buf[:])
+	return
+}
+
+// format a Flipper value of little-endian hex bytes
+func formatFlipperValue(v uint32) string {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return fmt.Sprintf("%02X %02X %02X %02X", buf[0], buf[1], buf[2], buf[3])
+}
+
+// Write signals as a Flipper Zero infrared file (.ir).
+// Signals identified as a protocol known to Flipper are written as parsed signals, and others as raw signals.
+func WriteFlipperIR(w io.Writer, signals []FlipperSignal) (err error) {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "Filetype: %s\nVersion: 1\n", flipperFileType)
+
+	for _, s := range signals {
+		fmt.Fprintf(bw, "# \nname: %s\n", s.Name)
+
+		if protocol, c, ok := flipperParsed(s.Code); ok {
+			fmt.Fprintf(bw, "type: parsed\nprotocol: %s\n", protocol)
+			fmt.Fprintf(bw, "address: %s\ncommand: %s\n", formatFlipperValue(c.Address), formatFlipperValue(c.Command))
+			continue
+		}
+
+		pulses, e := DecodeCode(s.Code)
+		if e != nil {
+			err = fmt.Errorf("signal %s: %v", s.Name, e)
+			return
+		}
+		if len(pulses)%2 == 0 {
+			// raw data ends with a mark
+			pulses = pulses[:len(pulses)-1]
+		}
+		freq := s.Frequency
+		if freq == 0 {
+			freq = defaultCarrierFrequency
+		}
+		duty := s.DutyCycle
+		if duty == 0 {
+			duty = defaultFlipperDuty
+		}
+		fmt.Fprintf(bw, "type: raw\nfrequency: %d\nduty_cycle: %f\ndata:", freq, duty)
+		for _, p := range pulses {
+			fmt.Fprintf(bw, " %d", p)
+		}
+		fmt.Fprintln(bw)
+	}
+	return bw.Flush()
+}
+
+// Get the signal with its carrier frequency and duty cycle.
+// The carrier is not checked here. Call sig.CheckCarrier() before sending the signal with a BroadLink device.
+func (s FlipperSignal) Signal() (sig *Signal, err error) {
+	if sig, err = NewSignal(REMOTE_IR, s.Code); err != nil {
+		return
+	}
+	sig.Frequency, sig.DutyCycle = s.Frequency, s.DutyCycle
+	return
+}
+
+// identify a code as a Flipper parsed signal
+func flipperParsed(code []byte) (protocol string, c IRCommand, ok bool) {
+	id, err := Identify(code)
+	if err != nil || id.Protocol == UnknownProtocol {
+		return
+	}
+	c = id.Command
+	switch c.Protocol {
+	case "NEC", "NECext", "Samsung32", "RC6", "SIRC", "SIRC15", "SIRC20":
+		protocol = c.Protocol
+		if protocol == "NECext" {
+			c.Command |= (^c.Command & 0xff) << 8 // Flipper writes the 16 bits of the command with the inverse
+		}
+		// NEC2 is written as raw, since Flipper repeats NEC with short repeat codes instead of full frames
+	case "RC5":
+		protocol = "RC5"
+		if c.Command >= 1<<flipperRC5CommandBits {
+			protocol = "RC5X"
+		}
+	default:
+		return
+	}
+	return protocol, c, true
+}
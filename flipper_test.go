@@ -0,0 +1,146 @@
+package broadlink
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testFlipperIR = `Filetype: IR signals file
+Version: 1
+# 
+name: Power
+type: parsed
+protocol: NEC
+address: 04 00 00 00
+command: 08 00 00 00
+# 
+name: Mute
+type: parsed
+protocol: RC5X
+address: 05 00 00 00
+command: 45 00 00 00
+# 
+name: Fan
+type: raw
+frequency: 38000
+duty_cycle: 0.330000
+data: 3000 1000 500 1500 500 500 1500 500 500
+`
+
+func TestReadFlipperIR(t *testing.T) {
+
+	signals, err := ReadFlipperIR(strings.NewReader(testFlipperIR))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signals) != 3 {
+		t.Fatalf("unexpected signal count %d", len(signals))
+	}
+
+	c, err := DecodeIRCommand(signals[0].Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != (IRCommand{Protocol: "NEC", Address: 0x04, Command: 0x08}) || signals[0].Frequency != 38000 {
+		t.Errorf("unexpected NEC signal %v", c)
+	}
+	c, err = DecodeIRCommand(signals[1].Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Protocol != "RC5" || c.Address != 5 || c.Command != 0x45 {
+		t.Errorf("unexpected RC5X signal %v", c)
+	}
+
+	pulses, err := DecodeCode(signals[2].Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulses) != 10 || !within(pulses[0], 3000) || !within(pulses[8], 500) {
+		t.Errorf("unexpected raw signal %v", pulses)
+	}
+	sig, err := signals[2].Signal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sig.Frequency != 38000 || sig.DutyCycle != 0.33 {
+		t.Errorf("unexpected carrier %d %g", sig.Frequency, sig.DutyCycle)
+	}
+
+	// write and read back
+	var buf bytes.Buffer
+	if err = WriteFlipperIR(&buf, signals); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, s := range []string{"protocol: NEC\naddress: 04 00 00 00\ncommand: 08 00 00 00", "protocol: RC5X\naddress: 05 00 00 00\ncommand: 45 00 00 00", "type: raw\nfrequency: 38000"} {
+		if !strings.Contains(out, s) {
+			t.Errorf("%q not found in output:\n%s", s, out)
+		}
+	}
+	again, err := ReadFlipperIR(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(again) != 3 || again[2].Name != "Fan" || !bytes.Equal(again[2].Code, signals[2].Code) {
+		t.Errorf("raw signal is not preserved")
+	}
+
+	// NEC2 repeats full frames, which Flipper cannot send as a parsed NEC signal
+	nec2, err := EncodeIRCommand(IRCommand{Protocol: "NEC2", Address: 4, Command: 8}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err = WriteFlipperIR(&buf, []FlipperSignal{{Name: "Power", Code: nec2}}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "type: raw") {
+		t.Errorf("NEC2 is not written as raw:\n%s", buf.String())
+	}
+
+	if _, err = ReadFlipperIR(strings.NewReader("Filetype: IR signals file\nname: X\ntype: parsed\nprotocol: NEC42\n")); err == nil {
+		t.Errorf("unsupported protocol is accepted")
+	}
+}
+
+func TestFlipperNECext(t *testing.T) {
+	const file = `Filetype: IR signals file
+Version: 1
+# 
+name: Input
+type: parsed
+protocol: NECext
+address: 34 12 00 00
+command: 12 34 00 00
+# 
+name: Power
+type: parsed
+protocol: NECext
+address: 34 12 00 00
+command: 02 FD 00 00
+`
+	signals, err := ReadFlipperIR(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulses, err := DecodeCode(signals[0].Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _, ok := readNECFrame(pulses, 0); !ok || data != 0x34121234 {
+		t.Errorf("unexpected NECext frame %08x", data)
+	}
+	if c, err := DecodeIRCommand(signals[1].Code); err != nil || c.Command != 0x02 {
+		t.Errorf("unexpected command %v %v", c, err)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteFlipperIR(&buf, signals[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "protocol: NECext\naddress: 34 12 00 00\ncommand: 02 FD 00 00") {
+		t.Errorf("unexpected output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,91 @@
+package broadlink
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse a Global Caché sendir command, e.g. "sendir,1:1,1,38000,2,1,342,171,21,...", into a code and a repeat count for SendRemoteControlCode().
+// The whole sequence is sent once and the part from offset is repeated. If offset is 1, the repeat maps directly onto count. Otherwise repeated parts are expanded into the code and count is 1.
+// freq is the carrier frequency in Hz. It is not checked; call CheckCarrier() before sending the code with a BroadLink device.
+func ParseGlobalCache(sendir string) (code []byte, count int, freq int, err error) {
+	fields := strings.Split(strings.TrimSpace(sendir), ",")
+	if len(fields) < 8 || strings.ToLower(strings.TrimSpace(fields[0])) != "sendir" {
+		err = fmt.Errorf("not a sendir command")
+		return
+	}
+	values := make([]int, len(fields)-3)
+	for i, f := range fields[3:] {
+		values[i], err = strconv.Atoi(strings.TrimSpace(f))
+		if err != nil || values[i] < 0 {
+			err = fmt.Errorf("invalid sendir value %q", f)
+			return
+		}
+	}
+	freq, repeat, offset, cycles := values[0], values[1], values[2], values[3:]
+	if freq <= 0 {
+		err = fmt.Errorf("invalid carrier frequency %d", freq)
+		return
+	}
+	if len(cycles)%2 != 0 {
+		err = fmt.Errorf("odd number of on/off values")
+		return
+	}
+	if repeat < 1 {
+		repeat = 1
+	}
+	if offset < 1 || offset%2 != 1 || offset > len(cycles) {
+		err = fmt.Errorf("invalid offset %d", offset)
+		return
+	}
+
+	pulses := make([]int, len(cycles))
+	for i, c := range cycles {
+		pulses[i] = int((int64(c)*1000000 + int64(freq)/2) / int64(freq)) // carrier cycles to microseconds
+	}
+
+	if offset == 1 {
+		code, count = EncodeCode(pulses), repeat
+		return
+	}
+	tail := pulses[offset-1:]
+	for i := 1; i < repeat; i++ {
+		pulses = append(pulses, tail...)
+	}
+	code, count = EncodeCode(pulses), 1
+	return
+}
+
+// Format a code and repeat count of SendRemoteControlCode() into a Global Caché sendir command for module 1, connector 1.
+// id is the command ID. freq is the carrier frequency in Hz. If freq is zero, 38kHz is assumed.
+func FormatGlobalCache(code []byte, count int, freq int, id int) (sendir string, err error) {
+	if freq == 0 {
+		freq = defaultCarrierFrequency
+	}
+	if freq < 0 {
+		err = fmt.Errorf("invalid carrier frequency %d", freq)
+		return
+	}
+	if count < 1 {
+		err = fmt.Errorf("count must be a positive integer")
+		return
+	}
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	pulses = evenPulses(pulses)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "sendir,1:1,%d,%d,%d,1", id, freq, count)
+	for _, p := range pulses {
+		c := (int64(p)*int64(freq) + 500000) / 1000000
+		if c < 1 {
+			c = 1
+		}
+		fmt.Fprintf(&sb, ",%d", c)
+	}
+	sendir = sb.String()
+	return
+}
@@ -0,0 +1,50 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestGlobalCache(t *testing.T) {
+
+	// offset 1: the repeat goes to the BroadLink repeat count
+	src := "sendir,1:1,1,38000,3,1,342,171,21,64,21,21,21,1520"
+	code, count, freq, err := ParseGlobalCache(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || freq != 38000 {
+		t.Fatalf("invalid count %d or frequency %d", count, freq)
+	}
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulses) != 8 || !within(pulses[0], 9000) || !within(pulses[3], 1684) {
+		t.Fatalf("invalid pulses %v", pulses)
+	}
+
+	formatted, err := FormatGlobalCache(code, count, freq, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatted != src {
+		t.Fatalf("round trip failed:\n%s\n%s", src, formatted)
+	}
+
+	// offset 3: repeated tail is expanded into the code
+	code, count, _, err = ParseGlobalCache("sendir,1:1,1,38000,3,3,342,171,21,64,21,1520")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulses, err = DecodeCode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 || len(pulses) != 14 {
+		t.Fatalf("invalid expansion: count %d, %d pulses", count, len(pulses))
+	}
+
+	if _, _, _, err = ParseGlobalCache("sendir,1:1,1,38000,1,2,342,171"); err == nil {
+		t.Error("even offset accepted")
+	}
+}
@@ -0,0 +1,183 @@
+package broadlink
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// Minimum space between frames in microseconds
+	frameGap = 5000
+
+	// Name of unidentified protocols
+	UnknownProtocol = "unknown"
+)
+
+// Result of Identify().
+type Identification struct {
+	Protocol   string    // protocol name, or UnknownProtocol
+	Command    IRCommand // decoded command. Valid if Protocol is not UnknownProtocol
+	Confidence float64   // from 0 to 1. How well the captured timings match the protocol
+	Timing     PulseTiming
+}
+
+// Measured timings of a pulse distance signal in microseconds.
+type PulseTiming struct {
+	HeaderMark  int // zero if the signal has no header
+	HeaderSpace int
+	BitMark     int
+	ZeroSpace   int // short space of a bit
+	OneSpace    int // long space of a bit. zero if all bits have the same space
+	Gap         int // space after the first frame
+	Bits        int // bits in the first frame
+	Frames      int // number of frames
+}
+
+// A short description such as "NEC, D=4 F=8" or "unknown, header 9000/4500, bit 560/560/1690, gap 40000, 32 bits, 1 frame".
+func (id Identification) String() string {
+	if id.Protocol != UnknownProtocol {
+		s := fmt.Sprintf("%s, D=%d F=%d", id.Protocol, id.Command.Address, id.Command.Command)
+		if p, ok := irProtocols[id.Protocol]; ok && p.toggle {
+			t := 0
+			if id.Command.Toggle {
+				t = 1
+			}
+			s += fmt.Sprintf(" T=%d", t)
+		}
+		return s
+	}
+	tm := id.Timing
+	parts := []string{UnknownProtocol}
+	if tm.HeaderMark > 0 {
+		parts = append(parts, fmt.Sprintf("header %d/%d", tm.HeaderMark, tm.HeaderSpace))
+	}
+	parts = append(parts, fmt.Sprintf("bit %d/%d/%d", tm.BitMark, tm.ZeroSpace, tm.OneSpace))
+	if tm.Gap > 0 {
+		parts = append(parts, fmt.Sprintf("gap %d", tm.Gap))
+	}
+	parts = append(parts, fmt.Sprintf("%d bits", tm.Bits))
+	if tm.Frames == 1 {
+		parts = append(parts, "1 frame")
+	} else {
+		parts = append(parts, fmt.Sprintf("%d frames", tm.Frames))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Identify the protocol of a captured IR code.
+// All protocol decoders are tried, and the one whose re-encoded signal matches the captured timings best is reported.
+// If no decoder recognizes the code, Protocol is UnknownProtocol and Timing has the measured timings.
+func Identify(code []byte) (id Identification, err error) {
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	id.Protocol = UnknownProtocol
+	id.Timing = measureTiming(pulses)
+
+	captured := SplitFrames(pulses, frameGap)
+	for _, p := range irProtocolOrder {
+		c, ok := p.decode(pulses)
+		if !ok {
+			continue
+		}
+		ref, e := p.encode(c, 0)
+		if e != nil {
+			continue
+		}
+		conf := frameSimilarity(captured[0], SplitFrames(ref, frameGap)[0])
+		if conf > id.Confidence {
+			id.Protocol, id.Command, id.Confidence = c.Protocol, c, conf
+		}
+	}
+	return
+}
+
+// Similarity of two frames from 0 to 1, by mean relative error of durations. Trailing gaps are ignored.
+func frameSimilarity(a, b []int) float64 {
+	a, b = frameBody(a), frameBody(b)
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	if n == 0 {
+		return 0
+	}
+	sum := 0.0
+	for i := 0; i < n; i++ {
+		e := float64(a[i]-b[i]) / float64(b[i])
+		if e < 0 {
+			e = -e
+		}
+		sum += e
+	}
+	s := 1 - sum/float64(n)/0.3 // 30% mean error is no match
+	if s < 0 {
+		s = 0
+	}
+	if len(a) != len(b) {
+		s *= float64(n) / float64(len(a)+len(b)-n)
+	}
+	return s
+}
+
+// Measure pulse distance timings of a signal.
+func measureTiming(pulses []int) (tm PulseTiming) {
+	frames := SplitFrames(pulses, frameGap)
+	tm.Frames = len(frames)
+	f := frames[0]
+	if len(f)%2 == 0 {
+		tm.Gap = f[len(f)-1]
+		f = f[:len(f)-1]
+	}
+	if len(f) == 0 {
+		return
+	}
+
+	marks, spaces := []int{}, []int{}
+	for i, d := range f {
+		if i%2 == 0 {
+			marks = append(marks, d)
+		} else {
+			spaces = append(spaces, d)
+		}
+	}
+	tm.BitMark = median(marks)
+
+	// a header is a leading mark much longer than bit marks
+	if len(f) >= 2 && f[0] > tm.BitMark*3 {
+		tm.HeaderMark, tm.HeaderSpace = f[0], f[1]
+		marks, spaces = marks[1:], spaces[1:]
+		tm.BitMark = median(marks)
+	}
+	tm.Bits = len(spaces)
+
+	// split spaces into short and long at the largest ratio between sorted values
+	if len(spaces) > 0 {
+		s := append([]int{}, spaces...)
+		sort.Ints(s)
+		split, ratio := 0, 1.5
+		for i := 1; i < len(s); i++ {
+			if r := float64(s[i]) / float64(s[i-1]); r > ratio {
+				split, ratio = i, r
+			}
+		}
+		if split == 0 {
+			tm.ZeroSpace = median(s)
+		} else {
+			tm.ZeroSpace, tm.OneSpace = median(s[:split]), median(s[split:])
+		}
+	}
+	return
+}
+
+// median of values
+func median(v []int) int {
+	if len(v) == 0 {
+		return 0
+	}
+	s := append([]int{}, v...)
+	sort.Ints(s)
+	return s[len(s)/2]
+}
@@ -0,0 +1,63 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestIdentify(t *testing.T) {
+
+	code, err := EncodeIRCommand(IRCommand{Protocol: "NEC", Address: 4, Command: 8}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := Identify(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.String() != "NEC, D=4 F=8" || id.Confidence < 0.95 {
+		t.Errorf("identified as %s (%.2f)", id, id.Confidence)
+	}
+	if id.Timing.HeaderMark == 0 || id.Timing.Bits != 32 || id.Timing.Frames != 2 {
+		t.Errorf("invalid timing %+v", id.Timing)
+	}
+
+	// jitter lowers the confidence
+	pulses, _ := DecodeCode(code)
+	for i := range pulses {
+		if i%2 == 0 {
+			pulses[i] += 100
+		} else {
+			pulses[i] -= 100
+		}
+	}
+	jittered, err := Identify(EncodeCode(pulses))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if jittered.Protocol != "NEC" || jittered.Confidence >= id.Confidence {
+		t.Errorf("jittered code identified as %s (%.2f)", jittered, jittered.Confidence)
+	}
+
+	// an unknown pulse distance protocol
+	var b pulseBuilder
+	b.mark(3000)
+	b.space(1500)
+	for i := 0; i < 20; i++ {
+		b.mark(400)
+		if i%3 == 0 {
+			b.space(1200)
+		} else {
+			b.space(400)
+		}
+	}
+	b.mark(400)
+	b.space(30000)
+	id, err = Identify(EncodeCode(b.pulses))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm := id.Timing
+	if id.Protocol != UnknownProtocol || !within(tm.HeaderMark, 3000) || !within(tm.ZeroSpace, 400) || !within(tm.OneSpace, 1200) || tm.Bits != 20 || !within(tm.Gap, 30000) {
+		t.Errorf("unknown code identified as %s", id)
+	}
+}
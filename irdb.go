@@ -0,0 +1,177 @@
+package broadlink
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A function (button) of an IRDB CSV file.
+type IRDBFunction struct {
+	Name string // function name, e.g. "POWER"
+	Code []byte // BroadLink IR code for SendIRRemoteCode()
+}
+
+// IRDB protocol names that differ from the names of IRPLibrary
+var irdbProtocolAliases = map[string]string{
+	"NEC": "NEC1",
+}
+
+// Read an IRDB CSV file of "functionname,protocol,device,subdevice,function" rows and render each function with IRPLibrary.
+// A subdevice of -1 means the protocol default. repeats is passed to EncodeIRP().
+func ReadIRDBCSV(r io.Reader, repeats int) (functions []IRDBFunction, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return
+	}
+
+	// column indexes, from the header if there is one
+	col := map[string]int{"functionname": 0, "protocol": 1, "device": 2, "subdevice": 3, "function": 4}
+	if len(rows) > 0 && strings.EqualFold(strings.TrimSpace(rows[0][0]), "functionname") {
+		for i, h := range rows[0] {
+			col[strings.ToLower(strings.TrimSpace(h))] = i
+		}
+		rows = rows[1:]
+	}
+
+	for n, row := range rows {
+		field := func(name string) string {
+			if i := col[name]; i < len(row) {
+				return strings.TrimSpace(row[i])
+			}
+			return ""
+		}
+		name, protocol := field("functionname"), field("protocol")
+		if name == "" && protocol == "" {
+			continue
+		}
+		if p, ok := irdbProtocolAliases[protocol]; ok {
+			protocol = p
+		}
+		params := map[string]int64{}
+		for _, p := range []struct{ key, column string }{{"D", "device"}, {"S", "subdevice"}, {"F", "function"}} {
+			s := field(p.column)
+			if s == "" {
+				continue
+			}
+			v, e := strconv.ParseInt(s, 10, 64)
+			if e != nil {
+				err = fmt.Errorf("row %d: invalid %s %q", n+1, p.column, s)
+				return
+			}
+			if v >= 0 {
+				params[p.key] = v
+			}
+		}
+		code, e := EncodeIRP(protocol, params, repeats)
+		if e != nil {
+			err = fmt.Errorf("row %d: %s: %v", n+1, name, e)
+			return
+		}
+		functions = append(functions, IRDBFunction{Name: name, Code: code})
+	}
+	return
+}
+
+// Format a code as an Arduino IRremote raw array, e.g. "uint16_t rawData[67] = {9000, 4500, ...};".
+// name is the array name; characters not allowed in a C identifier are replaced with '_'. If name is empty, "rawData" is used.
+// The array ends with a mark. It may be sent with IrSender.sendRaw(name, sizeof(name) / sizeof(name[0]), kHz).
+func FormatArduinoRawData(name string, code []byte) (s string, err error) {
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	if len(pulses)%2 == 0 {
+		pulses = pulses[:len(pulses)-1]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "" {
+		name = "rawData"
+	} else if unicode.IsDigit(rune(name[0])) {
+		name = "_" + name
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "uint16_t %s[%d] = {", name, len(pulses))
+	for i, p := range pulses {
+		if p > 0xffff {
+			p = 0xffff
+		}
+		if i > 0 {
+			sb.WriteString(", ")
+			if i%16 == 0 {
+				sb.WriteString("\n\t")
+			}
+		}
+		fmt.Fprintf(&sb, "%d", p)
+	}
+	sb.WriteString("};")
+	s = sb.String()
+	return
+}
+
+// Parse an Arduino IRremote raw array, e.g. "uint16_t rawData[67] = {9000,4500, 560,560, ...};", into a code for SendIRRemoteCode().
+// Only the values between the braces are read. Comments are ignored.
+func ParseArduinoRawData(s string) (code []byte, err error) {
+	// strip comments
+	var sb strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	s = sb.String()
+
+	start, end := strings.IndexByte(s, '{'), strings.IndexByte(s, '}')
+	if start < 0 || end < start {
+		err = fmt.Errorf("no array values")
+		return
+	}
+	var pulses []int
+	for _, f := range strings.FieldsFunc(s[start+1:end], func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+		v, e := strconv.ParseUint(f, 0, 32)
+		if e != nil || v == 0 {
+			err = fmt.Errorf("invalid duration %q", f)
+			return
+		}
+		pulses = append(pulses, int(v))
+	}
+	if len(pulses) == 0 {
+		err = fmt.Errorf("no array values")
+		return
+	}
+	code = EncodeCode(pulses)
+	return
+}
+
+// Convert a code into a carrier frequency and pattern for Android ConsumerIrManager.transmit().
+// freq is the carrier frequency in Hz. If freq is zero, 38kHz is assumed. The pattern is alternating on and off durations in microseconds, starting with on.
+func AndroidIRPattern(code []byte, freq int) (frequency int, pattern []int, err error) {
+	if freq == 0 {
+		freq = defaultCarrierFrequency
+	}
+	if freq < 0 {
+		err = fmt.Errorf("invalid carrier frequency %d", freq)
+		return
+	}
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	frequency, pattern = freq, pulses
+	return
+}
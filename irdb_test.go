@@ -0,0 +1,72 @@
+package broadlink
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadIRDBCSV(t *testing.T) {
+
+	csv := "functionname,protocol,device,subdevice,function\n" +
+		"POWER,NEC1,4,-1,8\n" +
+		"MUTE,NECx2,7,7,2\n" +
+		"VOL+,RC5,0,-1,16\n"
+	functions, err := ReadIRDBCSV(strings.NewReader(csv), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(functions) != 3 {
+		t.Fatalf("unexpected function count %d", len(functions))
+	}
+
+	want := []IRCommand{
+		{Protocol: "NEC", Address: 4, Command: 8},
+		{Protocol: "Samsung32", Address: 7, Command: 2}, // NECx has the timings of Samsung32
+		{Protocol: "RC5", Address: 0, Command: 16},
+	}
+	for i, f := range functions {
+		c, err := DecodeIRCommand(f.Code)
+		if err != nil {
+			t.Fatalf("%s: %v", f.Name, err)
+		}
+		if c.Protocol != want[i].Protocol || c.Address != want[i].Address || c.Command != want[i].Command {
+			t.Errorf("%s: decoded to %v", f.Name, c)
+		}
+	}
+
+	if _, err = ReadIRDBCSV(strings.NewReader("POWER,NoSuchProtocol,1,-1,2\n"), 0); err == nil {
+		t.Errorf("unknown protocol is accepted")
+	}
+}
+
+func TestArduinoRawData(t *testing.T) {
+
+	code := EncodeCode([]int{9000, 4500, 560, 560, 560, 1690, 560, 40000})
+	s, err := FormatArduinoRawData("power on", code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(s, "uint16_t power_on[7] = {") || !strings.HasSuffix(s, "};") {
+		t.Errorf("unexpected array %s", s)
+	}
+
+	parsed, err := ParseArduinoRawData(s + " // Protocol=UNKNOWN")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulses, err := DecodeCode(parsed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulses) != 8 || !within(pulses[0], 9000) || !within(pulses[5], 1690) {
+		t.Errorf("unexpected pulses %v", pulses)
+	}
+
+	freq, pattern, err := AndroidIRPattern(code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if freq != 38000 || len(pattern) != 8 || !within(pattern[1], 4500) {
+		t.Errorf("unexpected pattern %d %v", freq, pattern)
+	}
+}
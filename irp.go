@@ -0,0 +1,1046 @@
+package broadlink
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A protocol described in IRP notation, e.g.
+//
+//	{38.4k,564}<1,-1|1,-3>(16,-8,D:8,S:8,F:8,~F:8,1,^108m,(16,-4,1,^108m)*)[D:0..255,S:0..255=255-D,F:0..255]
+//
+// Supported are the general spec (frequency, unit, msb/lsb, duty cycle), bitspecs including nested and multi-bit ones,
+// flashes, gaps and extents with m/u/p units, bitfields with complement, reversal and offset, repeat markers (*, +, n, n+),
+// assignments, definitions, parameter specs with defaults and expressions of the usual operators.
+type IRPProtocol struct {
+	Frequency int     // carrier frequency in Hz
+	DutyCycle int     // duty cycle in percent. zero if not given
+	Unit      float64 // duration unit in microseconds
+	MSBFirst  bool    // bitfields are sent most significant bit first
+
+	bitspec     *irpBitspec
+	stream      *irpStream
+	definitions map[string]irpExpr
+	params      []irpParam
+}
+
+// A parameter of an IRP protocol.
+type irpParam struct {
+	name     string
+	min, max int64
+	def      irpExpr // default value. nil if the parameter is mandatory
+}
+
+// IRP expression.
+type irpExpr interface {
+	eval(env *irpEnv) (int64, error)
+}
+
+type irpNumber float64
+
+type irpName string
+
+type irpUnary struct {
+	op byte
+	x  irpExpr
+}
+
+type irpBinary struct {
+	op   string
+	x, y irpExpr
+}
+
+type irpBitfield struct {
+	x      irpExpr
+	width  irpExpr
+	offset irpExpr // nil for zero
+}
+
+// IRP irstream items.
+type irpDuration struct {
+	kind  byte // 'f' for flash, 'g' for gap and 'e' for extent
+	value irpExpr
+	unit  byte // 0 for the general unit, 'm', 'u' or 'p'
+}
+
+type irpBits struct {
+	x irpExpr // a bitfield, or a complemented bitfield
+}
+
+type irpAssign struct {
+	name  string
+	value irpExpr
+}
+
+type irpStream struct {
+	items   []interface{}
+	bitspec *irpBitspec // bitspec for this stream. nil to inherit
+	min     int         // minimum repeat count
+	repeat  bool        // repeated by repeats of Render(). "*" or "+"
+}
+
+type irpBitspec struct {
+	symbols [][]interface{}
+	bits    int // bits per symbol
+}
+
+// Evaluation environment of an IRP protocol.
+type irpEnv struct {
+	proto  *IRPProtocol
+	values map[string]int64
+	depth  int
+}
+
+func (v irpNumber) eval(env *irpEnv) (int64, error) {
+	return int64(v), nil
+}
+
+func (n irpName) eval(env *irpEnv) (int64, error) {
+	if v, ok := env.values[string(n)]; ok {
+		return v, nil
+	}
+	if d, ok := env.proto.definitions[string(n)]; ok {
+		if env.depth > 32 {
+			return 0, fmt.Errorf("recursive definition of %s", n)
+		}
+		env.depth++
+		v, err := d.eval(env)
+		env.depth--
+		return v, err
+	}
+	return 0, fmt.Errorf("undefined name %s", n)
+}
+
+func (u *irpUnary) eval(env *irpEnv) (v int64, err error) {
+	v, err = u.x.eval(env)
+	if err != nil {
+		return
+	}
+	switch u.op {
+	case '-':
+		v = -v
+	case '~':
+		v = ^v
+		if bf, ok := u.x.(*irpBitfield); ok {
+			// complement within the bitfield width
+			w, e := bf.size(env)
+			if e != nil {
+				return 0, e
+			}
+			v &= int64(1)<<uint(w) - 1
+		}
+	case '!':
+		if v == 0 {
+			v = 1
+		} else {
+			v = 0
+		}
+	case '#': // bit count
+		n := int64(0)
+		for x := uint64(v); x != 0; x &= x - 1 {
+			n++
+		}
+		v = n
+	}
+	return
+}
+
+func (b *irpBinary) eval(env *irpEnv) (v int64, err error) {
+	x, err := b.x.eval(env)
+	if err != nil {
+		return
+	}
+	y, err := b.y.eval(env)
+	if err != nil {
+		return
+	}
+	bool2int := func(c bool) int64 {
+		if c {
+			return 1
+		}
+		return 0
+	}
+	switch b.op {
+	case "||":
+		v = bool2int(x != 0 || y != 0)
+	case "&&":
+		v = bool2int(x != 0 && y != 0)
+	case "|":
+		v = x | y
+	case "^":
+		v = x ^ y
+	case "&":
+		v = x & y
+	case "==":
+		v = bool2int(x == y)
+	case "!=":
+		v = bool2int(x != y)
+	case "<":
+		v = bool2int(x < y)
+	case ">":
+		v = bool2int(x > y)
+	case "<=":
+		v = bool2int(x <= y)
+	case ">=":
+		v = bool2int(x >= y)
+	case "<<":
+		v = x << uint(y)
+	case ">>":
+		v = x >> uint(y)
+	case "+":
+		v = x + y
+	case "-":
+		v = x - y
+	case "*":
+		v = x * y
+	case "/", "%":
+		if y == 0 {
+			return 0, fmt.Errorf("division by zero")
+		}
+		if b.op == "/" {
+			v = x / y
+		} else {
+			v = x % y
+		}
+	case "**":
+		if y < 0 || y > 63 {
+			return 0, fmt.Errorf("exponent %d out of range", y)
+		}
+		v = 1
+		for i := int64(0); i < y; i++ {
+			p := v * x
+			if x != 0 && p/x != v {
+				return 0, fmt.Errorf("%d**%d overflows", x, y)
+			}
+			v = p
+		}
+	}
+	return
+}
+
+// get the absolute width of a bitfield
+func (bf *irpBitfield) size(env *irpEnv) (w int, err error) {
+	wv, err := bf.width.eval(env)
+	if err != nil {
+		return
+	}
+	if wv < 0 {
+		wv = -wv
+	}
+	if wv > 63 {
+		err = fmt.Errorf("bitfield too wide")
+		return
+	}
+	w = int(wv)
+	return
+}
+
+func (bf *irpBitfield) eval(env *irpEnv) (v int64, err error) {
+	x, err := bf.x.eval(env)
+	if err != nil {
+		return
+	}
+	wv, err := bf.width.eval(env)
+	if err != nil {
+		return
+	}
+	w, err := bf.size(env)
+	if err != nil {
+		return
+	}
+	var off int64
+	if bf.offset != nil {
+		off, err = bf.offset.eval(env)
+		if err != nil {
+			return
+		}
+	}
+	v = (x >> uint(off)) & (int64(1)<<uint(w) - 1)
+	if wv < 0 {
+		// reversed bit order
+		var r int64
+		for i := 0; i < w; i++ {
+			r = r<<1 | (v>>uint(i))&1
+		}
+		v = r
+	}
+	return
+}
+
+// get the bitfield of a bits item
+func bitfieldOf(x irpExpr) *irpBitfield {
+	switch e := x.(type) {
+	case *irpBitfield:
+		return e
+	case *irpUnary:
+		if e.op == '~' {
+			if bf, ok := e.x.(*irpBitfield); ok {
+				return bf
+			}
+		}
+	}
+	return nil
+}
+
+//
+// parser
+//
+
+type irpParser struct {
+	s         string
+	pos       int
+	inBitspec bool // '|', '<' and '>' are delimiters
+}
+
+func (p *irpParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("IRP: %s at position %d", fmt.Sprintf(format, args...), p.pos)
+}
+
+func (p *irpParser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// peek the next non-space character. 0 at the end.
+func (p *irpParser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+// consume a token if it comes next
+func (p *irpParser) accept(tok string) bool {
+	p.skipSpace()
+	if strings.HasPrefix(p.s[p.pos:], tok) {
+		p.pos += len(tok)
+		return true
+	}
+	return false
+}
+
+func (p *irpParser) expect(tok string) error {
+	if !p.accept(tok) {
+		return p.errorf("%q expected", tok)
+	}
+	return nil
+}
+
+func isIRPLetter(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_'
+}
+
+func isIRPDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func (p *irpParser) name() (n string, ok bool) {
+	p.skipSpace()
+	start := p.pos
+	if p.pos >= len(p.s) || !isIRPLetter(p.s[p.pos]) {
+		return
+	}
+	for p.pos < len(p.s) && (isIRPLetter(p.s[p.pos]) || isIRPDigit(p.s[p.pos])) {
+		p.pos++
+	}
+	return p.s[start:p.pos], true
+}
+
+func (p *irpParser) number() (v float64, ok bool) {
+	p.skipSpace()
+	start := p.pos
+	if strings.HasPrefix(p.s[p.pos:], "0x") || strings.HasPrefix(p.s[p.pos:], "0X") {
+		p.pos += 2
+		for p.pos < len(p.s) && strings.IndexByte("0123456789abcdefABCDEF", p.s[p.pos]) >= 0 {
+			p.pos++
+		}
+		n, err := strconv.ParseUint(p.s[start+2:p.pos], 16, 64)
+		if err != nil {
+			p.pos = start
+			return
+		}
+		return float64(n), true
+	}
+	for p.pos < len(p.s) && isIRPDigit(p.s[p.pos]) {
+		p.pos++
+	}
+	if start == p.pos {
+		return
+	}
+	// fraction. ".." of a parameter range is not a fraction
+	if p.pos+1 < len(p.s) && p.s[p.pos] == '.' && isIRPDigit(p.s[p.pos+1]) {
+		p.pos++
+		for p.pos < len(p.s) && isIRPDigit(p.s[p.pos]) {
+			p.pos++
+		}
+	}
+	v, err := strconv.ParseFloat(p.s[start:p.pos], 64)
+	if err != nil {
+		p.pos = start
+		return
+	}
+	return v, true
+}
+
+// binary operators by precedence, lowest first
+var irpBinaryOps = [][]string{
+	{"||"}, {"&&"}, {"|"}, {"^"}, {"&"}, {"==", "!="}, {"<=", ">=", "<", ">"}, {"<<", ">>"}, {"+", "-"}, {"*", "/", "%"},
+}
+
+func (p *irpParser) expr() (irpExpr, error) {
+	return p.binary(0)
+}
+
+func (p *irpParser) binary(level int) (x irpExpr, err error) {
+	if level == len(irpBinaryOps) {
+		return p.power()
+	}
+	x, err = p.binary(level + 1)
+	if err != nil {
+		return
+	}
+	for {
+		op := p.binaryOp(level)
+		if op == "" {
+			return
+		}
+		var y irpExpr
+		y, err = p.binary(level + 1)
+		if err != nil {
+			return
+		}
+		x = &irpBinary{op: op, x: x, y: y}
+	}
+}
+
+// consume a binary operator of a precedence level
+func (p *irpParser) binaryOp(level int) string {
+	p.skipSpace()
+	rest := p.s[p.pos:]
+	for _, op := range irpBinaryOps[level] {
+		if !strings.HasPrefix(rest, op) {
+			continue
+		}
+		if p.inBitspec && strings.ContainsAny(op, "|<>") {
+			return ""
+		}
+		// do not take a prefix of a longer operator
+		if len(op) == 1 && len(rest) > 1 && strings.Contains("|&<>*", op) && (rest[1] == op[0] || (op != "*" && rest[1] == '=')) {
+			continue
+		}
+		p.pos += len(op)
+		return op
+	}
+	return ""
+}
+
+func (p *irpParser) power() (x irpExpr, err error) {
+	x, err = p.unary()
+	if err != nil {
+		return
+	}
+	if p.accept("**") {
+		var y irpExpr
+		y, err = p.power()
+		if err != nil {
+			return
+		}
+		x = &irpBinary{op: "**", x: x, y: y}
+	}
+	return
+}
+
+func (p *irpParser) unary() (x irpExpr, err error) {
+	c := p.peek()
+	switch c {
+	case '-', '~', '!', '#':
+		p.pos++
+		x, err = p.unary()
+		if err != nil {
+			return
+		}
+		x = &irpUnary{op: c, x: x}
+		return
+	}
+	return p.bitfield()
+}
+
+// primary with an optional bitfield suffix
+func (p *irpParser) bitfield() (x irpExpr, err error) {
+	x, err = p.primary()
+	if err != nil {
+		return
+	}
+	if p.peek() != ':' {
+		return
+	}
+	p.pos++
+	bf := &irpBitfield{x: x}
+	if p.accept(":") {
+		// infinite bitfield x::offset is not supported for sending
+		err = p.errorf("infinite bitfield")
+		return
+	}
+	neg := p.accept("-")
+	bf.width, err = p.primary()
+	if err != nil {
+		return
+	}
+	if neg {
+		bf.width = &irpUnary{op: '-', x: bf.width}
+	}
+	if p.accept(":") {
+		bf.offset, err = p.primary()
+		if err != nil {
+			return
+		}
+	}
+	x = bf
+	return
+}
+
+func (p *irpParser) primary() (x irpExpr, err error) {
+	c := p.peek()
+	switch {
+	case c == '(':
+		p.pos++
+		saved := p.inBitspec
+		p.inBitspec = false
+		x, err = p.expr()
+		p.inBitspec = saved
+		if err != nil {
+			return
+		}
+		err = p.expect(")")
+		return
+	case isIRPDigit(c):
+		v, _ := p.number()
+		x = irpNumber(v)
+		return
+	case isIRPLetter(c):
+		n, _ := p.name()
+		x = irpName(n)
+		return
+	}
+	err = p.errorf("expression expected")
+	return
+}
+
+// parse a duration value with an optional unit suffix
+func (p *irpParser) duration(kind byte) (d *irpDuration, err error) {
+	v, err := p.bitfield()
+	if err != nil {
+		return
+	}
+	d = &irpDuration{kind: kind, value: v}
+	if p.pos < len(p.s) && strings.IndexByte("mup", p.s[p.pos]) >= 0 &&
+		(p.pos+1 >= len(p.s) || !isIRPLetter(p.s[p.pos+1]) && !isIRPDigit(p.s[p.pos+1])) {
+		d.unit = p.s[p.pos]
+		p.pos++
+	}
+	return
+}
+
+// parse an irstream item
+func (p *irpParser) item() (it interface{}, err error) {
+	switch p.peek() {
+	case '<':
+		spec, e := p.bitspec()
+		if e != nil {
+			return nil, e
+		}
+		st, e := p.stream()
+		if e != nil {
+			return nil, e
+		}
+		st.bitspec = spec
+		return st, nil
+	case '(':
+		// a parenthesized expression of a bitfield, or a nested irstream
+		start := p.pos
+		x, e := p.expr()
+		if e == nil && bitfieldOf(x) != nil && strings.IndexByte(",)", p.peek()) >= 0 {
+			return &irpBits{x: x}, nil
+		}
+		p.pos = start
+		return p.stream()
+	case '-':
+		p.pos++
+		return p.duration('g')
+	case '^':
+		p.pos++
+		return p.duration('e')
+	}
+
+	// assignment
+	start := p.pos
+	if n, ok := p.name(); ok && p.peek() == '=' && !strings.HasPrefix(p.s[p.pos:], "==") {
+		p.pos++
+		v, e := p.expr()
+		if e != nil {
+			return nil, e
+		}
+		return &irpAssign{name: n, value: v}, nil
+	}
+	p.pos = start
+
+	// bitfield or flash
+	if p.peek() == '~' {
+		x, e := p.unary()
+		if e != nil {
+			return nil, e
+		}
+		if bitfieldOf(x) == nil {
+			return nil, p.errorf("bitfield expected")
+		}
+		return &irpBits{x: x}, nil
+	}
+	d, err := p.duration('f')
+	if err != nil {
+		return
+	}
+	if _, ok := d.value.(*irpBitfield); ok {
+		if d.unit != 0 {
+			return nil, p.errorf("unit on a bitfield")
+		}
+		return &irpBits{x: d.value}, nil
+	}
+	return d, nil
+}
+
+// parse items separated by commas until one of the terminators
+func (p *irpParser) items(terminators string) (items []interface{}, err error) {
+	for {
+		if strings.IndexByte(terminators, p.peek()) >= 0 {
+			return
+		}
+		it, e := p.item()
+		if e != nil {
+			return nil, e
+		}
+		items = append(items, it)
+		if !p.accept(",") {
+			return
+		}
+	}
+}
+
+func (p *irpParser) stream() (st *irpStream, err error) {
+	if err = p.expect("("); err != nil {
+		return
+	}
+	saved := p.inBitspec
+	p.inBitspec = false
+	st = &irpStream{min: 1}
+	st.items, err = p.items(")")
+	p.inBitspec = saved
+	if err != nil {
+		return
+	}
+	if err = p.expect(")"); err != nil {
+		return
+	}
+
+	// repeat marker
+	p.skipSpace()
+	switch {
+	case p.accept("*"):
+		st.min, st.repeat = 0, true
+	case p.accept("+"):
+		st.min, st.repeat = 1, true
+	case isIRPDigit(p.peek()):
+		v, _ := p.number()
+		st.min = int(v)
+		st.repeat = p.accept("+")
+	}
+	return
+}
+
+func (p *irpParser) bitspec() (spec *irpBitspec, err error) {
+	if err = p.expect("<"); err != nil {
+		return
+	}
+	saved := p.inBitspec
+	p.inBitspec = true
+	defer func() { p.inBitspec = saved }()
+
+	spec = &irpBitspec{}
+	for {
+		sym, e := p.items("|>")
+		if e != nil {
+			return nil, e
+		}
+		spec.symbols = append(spec.symbols, sym)
+		if p.accept("|") {
+			continue
+		}
+		if err = p.expect(">"); err != nil {
+			return
+		}
+		break
+	}
+	n := len(spec.symbols)
+	for spec.bits = 0; 1<<uint(spec.bits) < n; spec.bits++ {
+	}
+	if n < 2 || 1<<uint(spec.bits) != n {
+		err = p.errorf("bitspec must have 2, 4, 8, ... symbols")
+	}
+	return
+}
+
+// parse the general spec
+func (p *irpParser) general(proto *IRPProtocol) (err error) {
+	if err = p.expect("{"); err != nil {
+		return
+	}
+	proto.Unit = 1
+	unitInPeriods := false
+	for p.peek() != '}' {
+		if n, ok := p.name(); ok {
+			switch strings.ToLower(n) {
+			case "msb":
+				proto.MSBFirst = true
+			case "lsb":
+				proto.MSBFirst = false
+			default:
+				return p.errorf("unknown general spec %s", n)
+			}
+		} else if v, ok := p.number(); ok {
+			switch {
+			case p.accept("k"):
+				proto.Frequency = int(v*1000 + 0.5)
+			case p.accept("%"):
+				proto.DutyCycle = int(v + 0.5)
+			case p.accept("p"):
+				proto.Unit, unitInPeriods = v, true
+			default:
+				proto.Unit = v
+			}
+		} else {
+			return p.errorf("invalid general spec")
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	if err = p.expect("}"); err != nil {
+		return
+	}
+	if unitInPeriods {
+		if proto.Frequency == 0 {
+			return p.errorf("unit in periods without frequency")
+		}
+		proto.Unit *= 1000000 / float64(proto.Frequency)
+	}
+	return
+}
+
+// parse definitions {name=expr,...}
+func (p *irpParser) definitions(proto *IRPProtocol) (err error) {
+	if err = p.expect("{"); err != nil {
+		return
+	}
+	for p.peek() != '}' {
+		n, ok := p.name()
+		if !ok || !p.accept("=") {
+			return p.errorf("definition expected")
+		}
+		proto.definitions[n], err = p.expr()
+		if err != nil {
+			return
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return p.expect("}")
+}
+
+// parse parameter specs [name:min..max=default,...]
+func (p *irpParser) parameters(proto *IRPProtocol) (err error) {
+	if err = p.expect("["); err != nil {
+		return
+	}
+	for p.peek() != ']' {
+		n, ok := p.name()
+		if !ok {
+			return p.errorf("parameter name expected")
+		}
+		p.accept("@") // memory parameter
+		if err = p.expect(":"); err != nil {
+			return
+		}
+		pr := irpParam{name: n}
+		min, ok1 := p.number()
+		if !ok1 || !p.accept("..") {
+			return p.errorf("parameter range expected")
+		}
+		max, ok2 := p.number()
+		if !ok2 {
+			return p.errorf("parameter range expected")
+		}
+		pr.min, pr.max = int64(min), int64(max)
+		if p.accept("=") {
+			pr.def, err = p.expr()
+			if err != nil {
+				return
+			}
+		}
+		proto.params = append(proto.params, pr)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return p.expect("]")
+}
+
+// Parse a protocol in IRP notation.
+func ParseIRP(irp string) (proto *IRPProtocol, err error) {
+	p := &irpParser{s: irp}
+	proto = &IRPProtocol{definitions: map[string]irpExpr{}}
+
+	if err = p.general(proto); err != nil {
+		return nil, err
+	}
+	if proto.bitspec, err = p.bitspec(); err != nil {
+		return nil, err
+	}
+	if proto.stream, err = p.stream(); err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek() {
+		case '{':
+			err = p.definitions(proto)
+		case '[':
+			err = p.parameters(proto)
+		case 0:
+			return
+		default:
+			err = p.errorf("unexpected character %q", p.s[p.pos])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+//
+// renderer
+//
+
+type irpRenderer struct {
+	proto   *IRPProtocol
+	env     *irpEnv
+	b       pulseBuilder
+	repeats int
+	elapsed float64 // exact duration rendered so far in microseconds
+	written int     // duration written into b
+}
+
+// append a duration in microseconds. Negative for a space.
+func (r *irpRenderer) emit(us float64) {
+	if us == 0 {
+		return
+	}
+	mark := us > 0
+	if !mark {
+		us = -us
+	}
+	// round on the accumulated time to avoid drift
+	r.elapsed += us
+	d := int(r.elapsed+0.5) - r.written
+	r.written += d
+	if mark {
+		r.b.mark(d)
+	} else {
+		r.b.space(d)
+	}
+}
+
+// evaluate a duration value in microseconds
+func (r *irpRenderer) durationValue(d *irpDuration) (us float64, err error) {
+	var v float64
+	if n, ok := d.value.(irpNumber); ok {
+		v = float64(n)
+	} else {
+		iv, e := d.value.eval(r.env)
+		if e != nil {
+			return 0, e
+		}
+		v = float64(iv)
+	}
+	switch d.unit {
+	case 'm':
+		us = v * 1000
+	case 'u':
+		us = v
+	case 'p':
+		if r.proto.Frequency == 0 {
+			return 0, fmt.Errorf("IRP: duration in periods without frequency")
+		}
+		us = v * 1000000 / float64(r.proto.Frequency)
+	default:
+		us = v * r.proto.Unit
+	}
+	return
+}
+
+// render an irstream. specs is the stack of bitspecs in scope, innermost last.
+func (r *irpRenderer) stream(st *irpStream, specs []*irpBitspec) (err error) {
+	if st.bitspec != nil {
+		specs = append(specs[:len(specs):len(specs)], st.bitspec)
+	}
+	n := st.min
+	if st.repeat {
+		n += r.repeats
+	}
+	for i := 0; i < n; i++ {
+		start := r.elapsed
+		if err = r.items(st.items, specs, &start); err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (r *irpRenderer) items(items []interface{}, specs []*irpBitspec, start *float64) (err error) {
+	for _, it := range items {
+		switch it := it.(type) {
+		case *irpDuration:
+			us, e := r.durationValue(it)
+			if e != nil {
+				return e
+			}
+			switch it.kind {
+			case 'f':
+				r.emit(us)
+			case 'g':
+				r.emit(-us)
+			case 'e':
+				gap := us - (r.elapsed - *start)
+				if gap <= 0 {
+					return fmt.Errorf("IRP: extent %v exceeded", us)
+				}
+				r.emit(-gap)
+				*start = r.elapsed
+			}
+		case *irpBits:
+			if err = r.bits(it, specs, start); err != nil {
+				return
+			}
+		case *irpAssign:
+			v, e := it.value.eval(r.env)
+			if e != nil {
+				return e
+			}
+			r.env.values[it.name] = v
+		case *irpStream:
+			if err = r.stream(it, specs); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// render a bitfield with the innermost bitspec. Bitfields in the bitspec symbols use the outer bitspecs.
+func (r *irpRenderer) bits(it *irpBits, specs []*irpBitspec, start *float64) (err error) {
+	if len(specs) == 0 {
+		return fmt.Errorf("IRP: bitfield without a bitspec")
+	}
+	spec, outer := specs[len(specs)-1], specs[:len(specs)-1]
+	w, err := bitfieldOf(it.x).size(r.env)
+	if err != nil {
+		return
+	}
+	v, err := it.x.eval(r.env)
+	if err != nil {
+		return
+	}
+	k := spec.bits
+	if w%k != 0 {
+		return fmt.Errorf("IRP: bitfield width %d is not a multiple of %d", w, k)
+	}
+	chunks := w / k
+	for i := 0; i < chunks; i++ {
+		shift := i * k
+		if r.proto.MSBFirst {
+			shift = (chunks - 1 - i) * k
+		}
+		sym := (v >> uint(shift)) & (int64(1)<<uint(k) - 1)
+		if err = r.items(spec.symbols[sym], outer, start); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Render the protocol with parameter values into alternating mark and space durations in microseconds.
+// Repeated irstreams ("*" and "+") are rendered repeats more times than their minimum count.
+// If the protocol has no intro sequence, the repeat sequence is rendered at least once.
+func (proto *IRPProtocol) Render(params map[string]int64, repeats int) (pulses []int, err error) {
+	values := map[string]int64{}
+	for n, v := range params {
+		known := false
+		for _, pr := range proto.params {
+			if pr.name == n {
+				known = true
+				if v < pr.min || v > pr.max {
+					return nil, fmt.Errorf("IRP: parameter %s=%d out of range %d..%d", n, v, pr.min, pr.max)
+				}
+			}
+		}
+		if !known && len(proto.params) > 0 {
+			return nil, fmt.Errorf("IRP: unknown parameter %s", n)
+		}
+		values[n] = v
+	}
+	for _, pr := range proto.params {
+		if _, ok := values[pr.name]; ok {
+			continue
+		}
+		if pr.def == nil {
+			return nil, fmt.Errorf("IRP: parameter %s is required", pr.name)
+		}
+		v, e := pr.def.eval(&irpEnv{proto: proto, values: values})
+		if e != nil {
+			return nil, e
+		}
+		values[pr.name] = v
+	}
+
+	for {
+		// assignments may change values, so each rendering starts from a copy
+		env := &irpEnv{proto: proto, values: make(map[string]int64, len(values))}
+		for n, v := range values {
+			env.values[n] = v
+		}
+		r := &irpRenderer{proto: proto, env: env, repeats: repeats}
+		if err = r.stream(proto.stream, []*irpBitspec{proto.bitspec}); err != nil {
+			return nil, err
+		}
+		if len(r.b.pulses) > 0 || repeats > 0 {
+			pulses = r.b.pulses
+			break
+		}
+		repeats = 1
+	}
+	if len(pulses) == 0 {
+		err = fmt.Errorf("IRP: empty signal")
+	}
+	return
+}
+
+// Render the protocol with parameter values into a code for SendIRRemoteCode(). See Render() for repeats.
+func (proto *IRPProtocol) Encode(params map[string]int64, repeats int) (code []byte, err error) {
+	pulses, err := proto.Render(params, repeats)
+	if err != nil {
+		return
+	}
+	code = EncodeCode(pulses)
+	return
+}
@@ -0,0 +1,115 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestIRP(t *testing.T) {
+
+	// every bundled protocol parses and renders
+	for name, irp := range IRPLibrary {
+		proto, err := ParseIRP(irp)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		params := map[string]int64{}
+		for _, p := range proto.params {
+			if p.def == nil {
+				params[p.name] = p.min + 1
+			}
+		}
+		pulses, err := proto.Render(params, 1)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(pulses) < 4 {
+			t.Errorf("%s: too short signal", name)
+		}
+	}
+
+	// IRP renderings are recognized by the protocol decoders
+	tests := []struct {
+		name   string
+		params map[string]int64
+		c      IRCommand
+	}{
+		{"NEC1", map[string]int64{"D": 4, "F": 8}, IRCommand{Protocol: "NEC", Address: 4, Command: 8}},
+		{"NEC2", map[string]int64{"D": 4, "F": 8}, IRCommand{Protocol: "NEC2", Address: 4, Command: 8}},
+		{"NECx2", map[string]int64{"D": 7, "F": 2}, IRCommand{Protocol: "Samsung32", Address: 7, Command: 2}},
+		{"RC5", map[string]int64{"D": 5, "F": 67}, IRCommand{Protocol: "RC5", Address: 5, Command: 67}},
+		{"RC6", map[string]int64{"D": 0, "F": 12, "T": 1}, IRCommand{Protocol: "RC6", Address: 0, Command: 12, Toggle: true}},
+		{"Sony12", map[string]int64{"D": 1, "F": 21}, IRCommand{Protocol: "SIRC", Address: 1, Command: 21}},
+		{"Sony20", map[string]int64{"D": 1, "S": 2, "F": 21}, IRCommand{Protocol: "SIRC20", Address: 1 | 2<<5, Command: 21}},
+		{"JVC", map[string]int64{"D": 3, "F": 23}, IRCommand{Protocol: "JVC", Address: 3, Command: 23}},
+		{"Sharp", map[string]int64{"D": 1, "F": 210}, IRCommand{Protocol: "Sharp", Address: 1, Command: 210}},
+	}
+	for _, tc := range tests {
+		code, err := EncodeIRP(tc.name, tc.params, 1)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		c, err := DecodeIRCommand(code)
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		if c != tc.c {
+			t.Errorf("%s: decoded as %v", tc.name, c)
+		}
+	}
+
+	// extents and repeats
+	proto, err := ParseIRP("{38k,500}<1,-1|1,-3>(16,-8,D:8,1,^100m,(16,-4,1,^100m)*)[D:0..255]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if proto.Frequency != 38000 || proto.Unit != 500 || proto.MSBFirst {
+		t.Errorf("invalid general spec %v", proto)
+	}
+	pulses, err := proto.Render(map[string]int64{"D": 0x81}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulses) != 2+16+2+4+4 {
+		t.Fatalf("unexpected pulse count %d", len(pulses))
+	}
+	total := 0
+	for _, p := range pulses[:20] {
+		total += p
+	}
+	if total != 100000 {
+		t.Errorf("extent not applied: %d", total)
+	}
+
+	if _, err = proto.Render(map[string]int64{"D": 256}, 0); err == nil {
+		t.Error("out of range parameter accepted")
+	}
+	if _, err = proto.Render(nil, 0); err == nil {
+		t.Error("missing parameter accepted")
+	}
+	for _, exp := range []string{"2**9999999999", "2**-1", "3**40"} {
+		proto, err := ParseIRP("{38k,564}<1,-1|1,-3>(1,(" + exp + "):8,^100m)[D:0..255]")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = proto.Render(map[string]int64{"D": 1}, 0); err == nil {
+			t.Errorf("exponent %s accepted", exp)
+		}
+	}
+	proto, err = ParseIRP("{38k,564}<1,-1|1,-3>(1,(3**39):8,^100m)[D:0..255]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = proto.Render(map[string]int64{"D": 1}, 0); err != nil {
+		t.Error(err)
+	}
+
+	for _, bad := range []string{"{38k}<1,-1|1,-3>(D:8", "{38k}<1,-1|1,-3|1>(D:8)", "(D:8)", "{38k}<1,-1|1,-3>(D:8)[D:0]"} {
+		if _, err = ParseIRP(bad); err == nil {
+			t.Errorf("invalid IRP accepted: %s", bad)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package broadlink
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	// Bundled IR protocols in IRP notation, keyed by the protocol names of IrpTransmogrifier.
+	// Parameters are D (device), S (subdevice), F (function) and protocol specific ones such as T (toggle).
+	// Only common consumer protocols are bundled, not the several hundred of the IrpTransmogrifier database (IrpProtocols.xml).
+	// Add entries from the database to extend the library. Protocols using IRP features that ParseIRP() does not support cannot be added.
+	IRPLibrary = map[string]string{
+		"NEC1":         "{38.4k,564}<1,-1|1,-3>(16,-8,D:8,S:8,F:8,~F:8,1,^108m,(16,-4,1,^108m)*)[D:0..255,S:0..255=255-D,F:0..255]",
+		"NEC2":         "{38.4k,564}<1,-1|1,-3>(16,-8,D:8,S:8,F:8,~F:8,1,^108m)+[D:0..255,S:0..255=255-D,F:0..255]",
+		"NECx1":        "{38.4k,564}<1,-1|1,-3>(8,-8,D:8,S:8,F:8,~F:8,1,^108m,(8,-8,D:1,1,^108m)*)[D:0..255,S:0..255=D,F:0..255]",
+		"NECx2":        "{38.4k,564}<1,-1|1,-3>(8,-8,D:8,S:8,F:8,~F:8,1,^108m)+[D:0..255,S:0..255=D,F:0..255]",
+		"Pioneer":      "{40k,564}<1,-1|1,-3>(16,-8,D:8,S:8,F:8,~F:8,1,^108m)+[D:0..255,S:0..255=255-D,F:0..255]",
+		"Aiwa":         "{38k,550}<1,-1|1,-3>(16,-8,D:8,S:5,~D:8,~S:5,F:8,~F:8,1,-42,(16,-8,1,-165)*)[D:0..255,S:0..31,F:0..255]",
+		"RC5":          "{36k,msb,889}<1,-1|-1,1>((1,~F:1:6,T:1,D:5,F:6,^114m)+,T=1-T)[D:0..31,F:0..127,T@:0..1=0]",
+		"RC5x":         "{36k,msb,889}<1,-1|-1,1>((1,~S:1:6,T:1,D:5,-4,S:6,F:6,^114m)+,T=1-T)[D:0..31,S:0..127,F:0..63,T@:0..1=0]",
+		"RC6":          "{36k,444,msb}<-1,1|1,-1>((6,-2,1:1,0:3,<-2,2|2,-2>(T:1),D:8,F:8,^107m)+,T=1-T)[D:0..255,F:0..255,T@:0..1=0]",
+		"Sony12":       "{40k,600}<1,-1|2,-1>(4,-1,F:7,D:5,^45m)+[D:0..31,F:0..127]",
+		"Sony15":       "{40k,600}<1,-1|2,-1>(4,-1,F:7,D:8,^45m)+[D:0..255,F:0..127]",
+		"Sony20":       "{40k,600}<1,-1|2,-1>(4,-1,F:7,D:5,S:8,^45m)+[D:0..31,S:0..255,F:0..127]",
+		"JVC":          "{38k,525}<1,-1|1,-3>(16,-8,(D:8,F:8,1,^59.08m)+)[D:0..255,F:0..255]",
+		"Panasonic":    "{37k,432}<1,-1|1,-3>(8,-4,2:8,32:8,D:8,S:8,F:8,(D^S^F):8,1,-173)+[D:0..255,S:0..255,F:0..255]",
+		"Denon-K":      "{37k,432}<1,-1|1,-3>(8,-4,84:8,50:8,0:4,D:4,S:4,F:12,((D*16)^S^(F*16)^(F:8:4)):8,1,-173)+[D:0..15,S:0..15,F:0..4095]",
+		"Denon":        "{38k,264}<1,-3|1,-7>(D:5,F:8,0:2,1,-165,D:5,~F:8,3:2,1,-165)+[D:0..31,F:0..255]",
+		"Sharp":        "{38k,264}<1,-3|1,-7>(D:5,F:8,1:2,1,-165,D:5,~F:8,2:2,1,-165)+[D:0..31,F:0..255]",
+		"Mitsubishi":   "{32.6k,300}<1,-3|1,-7>(D:8,F:8,1,-80)+[D:0..255,F:0..255]",
+		"RCA":          "{58k,460,msb}<1,-2|1,-4>(8,-8,D:4,F:8,~D:4,~F:8,1,-16)+[D:0..15,F:0..255]",
+		"GI_Cable":     "{38.7k,490}<1,-4.5|1,-9>(18,-9,F:8,D:4,C:4,1,-84,(18,-4.5,1,-178)*){C=-(D+F:4+F:4:4)}[D:0..15,F:0..255]",
+		"Nokia32":      "{36k,msb}<164,-276|164,-445|164,-614|164,-783>(412,-276,D:8,S:8,X:8,F:8,164,^100m)+[D:0..255,S:0..255,F:0..255,X:0..255=0]",
+		"Dish_Network": "{57.6k,400}<1,-7|1,-4>(1,-15,(F:-6,U:5,D:5,1,-15)+)[F:0..63,D:0..31,U:0..31=0]",
+		"Kaseikyo_JVC": "{37k,432}<1,-1|1,-3>(8,-4,3:8,1:8,D:8,S:8,F:8,(D^S^F):8,1,-173)+[D:0..255,S:0..255,F:0..255]",
+		"Sony8":        "{40k,600}<1,-1|2,-1>(4,-1,F:8,^45m)+[F:0..255]",
+		"Apple":        "{38.4k,564}<1,-1|1,-3>(16,-8,D:8,S:8,C:1,F:7,PairID:8,1,^108m,(16,-4,1,^108m)*){C=1-(#D+#S+#F+#PairID)%2,S=135}[D:0..255=238,F:0..127,PairID:0..255]",
+		"Proton":       "{38k,500}<1,-1|1,-3>(16,-8,D:8,1,-8,F:8,1,^63m)+[D:0..255,F:0..255]",
+		"Samsung20":    "{38.4k,564}<1,-1|1,-3>(8,-8,D:6,S:6,F:8,1,^46m)+[D:0..63,S:0..63,F:0..255]",
+		"Fujitsu":      "{37k,432}<1,-1|1,-3>(8,-4,20:8,99:8,0:4,E:4,D:8,S:8,F:8,1,-110)+[D:0..255,S:0..255,F:0..255,E:0..15=0]",
+	}
+)
+
+// Render a protocol of IRPLibrary into a code for SendIRRemoteCode().
+// name is a protocol name of IRPLibrary, case insensitive. params are parameter values such as D, S and F. See IRPProtocol.Render() for repeats.
+func EncodeIRP(name string, params map[string]int64, repeats int) (code []byte, err error) {
+	irp, ok := IRPLibrary[name]
+	if !ok {
+		for n, v := range IRPLibrary {
+			if strings.EqualFold(n, name) {
+				irp, ok = v, true
+				break
+			}
+		}
+	}
+	if !ok {
+		err = fmt.Errorf("IRP protocol %s not found", name)
+		return
+	}
+	proto, err := ParseIRP(irp)
+	if err != nil {
+		return
+	}
+	return proto.Encode(params, repeats)
+}
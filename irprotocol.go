@@ -0,0 +1,224 @@
+package broadlink
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A command of an IR protocol.
+type IRCommand struct {
+	Protocol string // protocol name. See IRProtocols() for supported names
+	Address  uint32 // device address. Extended addresses of NECext and similar protocols are sent in little-endian byte order
+	Command  uint32 // command (function) code
+	Toggle   bool   // toggle bit of RC5 and RC6. Flipped automatically by Device.SendIRCommand()
+}
+
+var (
+	ErrUnknownProtocol = fmt.Errorf("unknown IR protocol") // The protocol name is unknown, or a code is not recognized as any known protocol
+)
+
+// An IR protocol codec.
+type irProtocol struct {
+	name      string
+	frequency int  // carrier frequency in Hz
+	toggle    bool // protocol has a toggle bit
+
+	// render a command into pulses. repeats is the number of repeat frames after the first frame.
+	encode func(c IRCommand, repeats int) (pulses []int, err error)
+
+	// recognize pulses as a command of the protocol
+	decode func(pulses []int) (c IRCommand, ok bool)
+}
+
+var (
+	irProtocols     = map[string]*irProtocol{}
+	irProtocolOrder []*irProtocol // decoding order
+)
+
+// Register an IR protocol codec. Called from init() of each protocol implementation.
+func registerIRProtocol(p *irProtocol) {
+	irProtocols[p.name] = p
+	if p.decode != nil {
+		irProtocolOrder = append(irProtocolOrder, p)
+	}
+}
+
+// Get the names of supported IR protocols.
+func IRProtocols() (names []string) {
+	for n := range irProtocols {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return
+}
+
+// Encode an IR command into a code for SendIRRemoteCode().
+// repeats is the number of repeat frames following the first frame. Note that SendIRRemoteCode() repeats the whole code including repeat frames.
+func EncodeIRCommand(c IRCommand, repeats int) (code []byte, err error) {
+	p, ok := irProtocols[c.Protocol]
+	if !ok {
+		err = ErrUnknownProtocol
+		return
+	}
+	if repeats < 0 {
+		err = fmt.Errorf("repeats must not be negative")
+		return
+	}
+	pulses, err := p.encode(c, repeats)
+	if err != nil {
+		return
+	}
+	code = EncodeCode(pulses)
+	return
+}
+
+// Decode a BroadLink IR code into a command of a known protocol.
+// If the code is not recognized, err is ErrUnknownProtocol.
+func DecodeIRCommand(code []byte) (c IRCommand, err error) {
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	for _, p := range irProtocolOrder {
+		if cmd, ok := p.decode(pulses); ok {
+			c = cmd
+			return
+		}
+	}
+	err = ErrUnknownProtocol
+	return
+}
+
+// Send an IR command. repeats is the number of repeat frames following the first frame.
+// For protocols with a toggle bit, c.Toggle is ignored and the device flips the toggle on each call so that the receiver recognizes a new key press.
+// Commands of protocols with a carrier the device cannot emit are not sent, and err wraps ErrUnsupportedCarrier.
+func (d *Device) SendIRCommand(c IRCommand, repeats int) (err error) {
+	p, ok := irProtocols[c.Protocol]
+	if !ok {
+		err = ErrUnknownProtocol
+		return
+	}
+	if p.toggle {
+		c.Toggle = d.irToggle[p.name]
+	}
+	code, err := EncodeIRCommand(c, repeats)
+	if err != nil {
+		return
+	}
+	if err = d.sendIRCode(code, p.frequency); err != nil {
+		return // the toggle is kept, so that a retry is not taken as a repeat of the last command
+	}
+	if p.toggle {
+		d.nextIRToggle(p.name)
+	}
+	return
+}
+
+// Send an IR code modulated at freq in Hz. Codes of a carrier the device cannot emit are not sent.
+func (d *Device) sendIRCode(code []byte, freq int) (err error) {
+	if err = CheckCarrier(freq); err != nil {
+		return
+	}
+	return d.SendIRRemoteCode(code, 1)
+}
+
+// Get the toggle bit for the next command of a protocol and flip the stored state.
+func (d *Device) nextIRToggle(protocol string) (toggle bool) {
+	if d.irToggle == nil {
+		d.irToggle = make(map[string]bool)
+	}
+	toggle = d.irToggle[protocol]
+	d.irToggle[protocol] = !toggle
+	return
+}
+
+// check a measured duration matches an expected duration in microseconds.
+// Captured durations have tick granularity and receiver jitter, so the tolerance is relative with an absolute floor.
+func matchDuration(measured, expected int) bool {
+	tol := expected * 3 / 10
+	if tol < 150 {
+		tol = 150
+	}
+	d := measured - expected
+	return -tol <= d && d <= tol
+}
+
+// check a measured space is long enough to be a gap between frames.
+// The final gap of a code may be cut short or extended by the capture, so only a lower bound is checked.
+func matchGap(pulses []int, i int, minGap int) bool {
+	return i >= len(pulses) || pulses[i] >= minGap
+}
+
+// Timings of a pulse distance protocol in microseconds.
+type pulseDistance struct {
+	headerMark, headerSpace int
+	bitMark                 int
+	zeroSpace, oneSpace     int
+	msbFirst                bool
+}
+
+// append a header
+func (pd *pulseDistance) header(b *pulseBuilder) {
+	b.mark(pd.headerMark)
+	b.space(pd.headerSpace)
+}
+
+// append nbits of data
+func (pd *pulseDistance) bits(b *pulseBuilder, data uint64, nbits int) {
+	for i := 0; i < nbits; i++ {
+		shift := uint(i)
+		if pd.msbFirst {
+			shift = uint(nbits - 1 - i)
+		}
+		b.mark(pd.bitMark)
+		if (data>>shift)&1 == 1 {
+			b.space(pd.oneSpace)
+		} else {
+			b.space(pd.zeroSpace)
+		}
+	}
+}
+
+// match a header at pulses[i]
+func (pd *pulseDistance) matchHeader(pulses []int, i int) bool {
+	return i+1 < len(pulses) && matchDuration(pulses[i], pd.headerMark) && matchDuration(pulses[i+1], pd.headerSpace)
+}
+
+// read nbits of data from pulses[i]. next is the index after the bits.
+func (pd *pulseDistance) readBits(pulses []int, i int, nbits int) (data uint64, next int, ok bool) {
+	if i+nbits*2 > len(pulses) {
+		return
+	}
+	threshold := (pd.zeroSpace + pd.oneSpace) / 2
+	for n := 0; n < nbits; n++ {
+		mark, space := pulses[i+n*2], pulses[i+n*2+1]
+		if !matchDuration(mark, pd.bitMark) {
+			return
+		}
+		var bit uint64
+		switch {
+		case matchDuration(space, pd.zeroSpace) && space < threshold:
+		case matchDuration(space, pd.oneSpace) && space >= threshold:
+			bit = 1
+		default:
+			return
+		}
+		if pd.msbFirst {
+			data = data<<1 | bit
+		} else {
+			data |= bit << uint(n)
+		}
+	}
+	next, ok = i+nbits*2, true
+	return
+}
+
+// Append a space to make the total duration of pulses since start to be period microseconds.
+// At least minGap microseconds of space is added.
+func padFrame(b *pulseBuilder, start, period, minGap int) {
+	gap := period - (b.duration() - start)
+	if gap < minGap {
+		gap = minGap
+	}
+	b.space(gap)
+}
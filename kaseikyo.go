@@ -0,0 +1,226 @@
+package broadlink
+
+import (
+	"fmt"
+)
+
+// Samsung, Kaseikyo, JVC and Sharp protocols.
+//
+//	"Samsung32"      8-bit address sent twice, 8-bit command with its inverse. Repeats are full frames.
+//	"Kaseikyo"       48 bits of 16-bit vendor ID, vendor parity, 12-bit address, 8-bit command and parity. Address = vendorID<<16 | address
+//	"Panasonic"      Kaseikyo with the Panasonic vendor ID (0x2002). 12-bit address
+//	"Kaseikyo_Denon" Kaseikyo with the Denon vendor ID (0x3254). 12-bit address
+//	"JVC"            8-bit address, 8-bit command. Repeats are frames without a header.
+//	"Sharp"          5-bit address, 8-bit command. Each frame is followed by a frame with inverted command.
+
+var samsungTiming = pulseDistance{
+	headerMark: 4500, headerSpace: 4500,
+	bitMark:   560,
+	zeroSpace: 560, oneSpace: 1690,
+}
+
+var kaseikyoTiming = pulseDistance{
+	headerMark: 432 * 8, headerSpace: 432 * 4,
+	bitMark:   432,
+	zeroSpace: 432, oneSpace: 432 * 3,
+}
+
+var jvcTiming = pulseDistance{
+	headerMark: 8400, headerSpace: 4200,
+	bitMark:   525,
+	zeroSpace: 525, oneSpace: 1575,
+}
+
+var sharpTiming = pulseDistance{
+	bitMark:   264,
+	zeroSpace: 264 * 3, oneSpace: 264 * 7,
+}
+
+const (
+	samsungPeriod  = 108000
+	kaseikyoPeriod = 130000
+	jvcPeriod      = 59080
+	sharpGap       = 264 * 165
+)
+
+var kaseikyoVendors = map[string]uint32{
+	"Panasonic":      0x2002,
+	"Kaseikyo_Denon": 0x3254,
+}
+
+func init() {
+	registerIRProtocol(&irProtocol{name: "Samsung32", frequency: 38000, encode: encodeSamsung32, decode: decodeSamsung32})
+	registerIRProtocol(&irProtocol{name: "Kaseikyo", frequency: 37000, encode: encodeKaseikyo, decode: decodeKaseikyo})
+	for name := range kaseikyoVendors {
+		registerIRProtocol(&irProtocol{name: name, frequency: 37000, encode: encodeKaseikyo})
+	}
+	registerIRProtocol(&irProtocol{name: "JVC", frequency: 38000, encode: encodeJVC, decode: decodeJVC})
+	registerIRProtocol(&irProtocol{name: "Sharp", frequency: 38000, encode: encodeSharp, decode: decodeSharp})
+}
+
+func encodeSamsung32(c IRCommand, repeats int) (pulses []int, err error) {
+	if c.Address > 0xff || c.Command > 0xff {
+		err = fmt.Errorf("Samsung32 address and command must be 8 bits")
+		return
+	}
+	data := uint64(c.Address) | uint64(c.Address)<<8 | uint64(c.Command)<<16 | uint64(^c.Command&0xff)<<24
+	var b pulseBuilder
+	for f := 0; f <= repeats; f++ {
+		start := b.duration()
+		samsungTiming.header(&b)
+		samsungTiming.bits(&b, data, 32)
+		b.mark(samsungTiming.bitMark)
+		padFrame(&b, start, samsungPeriod, necMinGap)
+	}
+	pulses = b.pulses
+	return
+}
+
+func decodeSamsung32(pulses []int) (c IRCommand, ok bool) {
+	if !samsungTiming.matchHeader(pulses, 0) {
+		return
+	}
+	data, next, ok := samsungTiming.readBits(pulses, 2, 32)
+	if !ok || next >= len(pulses) || !matchDuration(pulses[next], samsungTiming.bitMark) || !matchGap(pulses, next+1, necMinGap) {
+		ok = false
+		return
+	}
+	addr, addr2, cmd, icmd := data&0xff, data>>8&0xff, data>>16&0xff, data>>24&0xff
+	if addr != addr2 || cmd^icmd != 0xff {
+		ok = false
+		return
+	}
+	c = IRCommand{Protocol: "Samsung32", Address: uint32(addr), Command: uint32(cmd)}
+	return
+}
+
+// 4-bit parity of a Kaseikyo vendor ID
+func kaseikyoVendorParity(vendor uint32) uint64 {
+	p := vendor ^ vendor>>8
+	return uint64((p ^ p>>4) & 0xf)
+}
+
+func encodeKaseikyo(c IRCommand, repeats int) (pulses []int, err error) {
+	vendor, ok := kaseikyoVendors[c.Protocol]
+	addr := c.Address
+	if !ok {
+		vendor, addr = c.Address>>16, c.Address&0xffff
+	}
+	if addr > 0xfff || c.Command > 0xff {
+		err = fmt.Errorf("%s address must be 12 bits and command 8 bits", c.Protocol)
+		return
+	}
+	// bytes 2-3: vendor parity and address, byte 4: command, byte 5: parity of bytes 2-4
+	low := kaseikyoVendorParity(vendor) | uint64(addr)<<4
+	parity := (low ^ low>>8 ^ uint64(c.Command)) & 0xff
+	data := uint64(vendor) | low<<16 | uint64(c.Command)<<32 | parity<<40
+
+	var b pulseBuilder
+	for f := 0; f <= repeats; f++ {
+		start := b.duration()
+		kaseikyoTiming.header(&b)
+		kaseikyoTiming.bits(&b, data, 48)
+		b.mark(kaseikyoTiming.bitMark)
+		padFrame(&b, start, kaseikyoPeriod, necMinGap)
+	}
+	pulses = b.pulses
+	return
+}
+
+func decodeKaseikyo(pulses []int) (c IRCommand, ok bool) {
+	if !kaseikyoTiming.matchHeader(pulses, 0) {
+		return
+	}
+	data, next, ok := kaseikyoTiming.readBits(pulses, 2, 48)
+	if !ok || next >= len(pulses) || !matchDuration(pulses[next], kaseikyoTiming.bitMark) || !matchGap(pulses, next+1, necMinGap) {
+		ok = false
+		return
+	}
+	vendor := uint32(data & 0xffff)
+	low, cmd, parity := data>>16&0xffff, data>>32&0xff, data>>40&0xff
+	if low&0xf != kaseikyoVendorParity(vendor) || (low^low>>8^cmd)&0xff != parity {
+		ok = false
+		return
+	}
+	c.Protocol = "Kaseikyo"
+	c.Address = vendor<<16 | uint32(low>>4)
+	c.Command = uint32(cmd)
+	for name, v := range kaseikyoVendors {
+		if v == vendor {
+			c.Protocol, c.Address = name, uint32(low>>4)
+		}
+	}
+	return
+}
+
+func encodeJVC(c IRCommand, repeats int) (pulses []int, err error) {
+	if c.Address > 0xff || c.Command > 0xff {
+		err = fmt.Errorf("JVC address and command must be 8 bits")
+		return
+	}
+	data := uint64(c.Address) | uint64(c.Command)<<8
+	var b pulseBuilder
+	for f := 0; f <= repeats; f++ {
+		start := b.duration()
+		if f == 0 {
+			jvcTiming.header(&b)
+		}
+		jvcTiming.bits(&b, data, 16)
+		b.mark(jvcTiming.bitMark)
+		padFrame(&b, start, jvcPeriod, necMinGap)
+	}
+	pulses = b.pulses
+	return
+}
+
+func decodeJVC(pulses []int) (c IRCommand, ok bool) {
+	if !jvcTiming.matchHeader(pulses, 0) {
+		return
+	}
+	data, next, ok := jvcTiming.readBits(pulses, 2, 16)
+	if !ok || next >= len(pulses) || !matchDuration(pulses[next], jvcTiming.bitMark) || !matchGap(pulses, next+1, necMinGap) {
+		ok = false
+		return
+	}
+	c = IRCommand{Protocol: "JVC", Address: uint32(data & 0xff), Command: uint32(data >> 8)}
+	return
+}
+
+func encodeSharp(c IRCommand, repeats int) (pulses []int, err error) {
+	if c.Address > 0x1f || c.Command > 0xff {
+		err = fmt.Errorf("Sharp address must be 5 bits and command 8 bits")
+		return
+	}
+	// address, command, expansion and check bits. The second frame has inverted command, expansion and check bits.
+	data := uint64(c.Address) | uint64(c.Command)<<5 | 1<<13
+	inverted := data ^ 0x7fe0
+	var b pulseBuilder
+	for f := 0; f <= repeats; f++ {
+		for _, d := range []uint64{data, inverted} {
+			sharpTiming.bits(&b, d, 15)
+			b.mark(sharpTiming.bitMark)
+			b.space(sharpGap)
+		}
+	}
+	pulses = b.pulses
+	return
+}
+
+func decodeSharp(pulses []int) (c IRCommand, ok bool) {
+	var frames [2]uint64
+	next := 0
+	for i := range frames {
+		frames[i], next, ok = sharpTiming.readBits(pulses, next, 15)
+		if !ok || next >= len(pulses) || !matchDuration(pulses[next], sharpTiming.bitMark) || !matchGap(pulses, next+1, necMinGap) {
+			ok = false
+			return
+		}
+		next += 2
+	}
+	if frames[0]>>13 != 1 || frames[0]^frames[1] != 0x7fe0 {
+		ok = false
+		return
+	}
+	c = IRCommand{Protocol: "Sharp", Address: uint32(frames[0] & 0x1f), Command: uint32(frames[0] >> 5 & 0xff)}
+	return
+}
@@ -0,0 +1,57 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestSamsungKaseikyoJVCSharp(t *testing.T) {
+
+	tests := []struct {
+		c      IRCommand
+		pulses []int // reference leading pulses
+		count  int   // pulse count with 1 repeat
+	}{
+		// header, address 0x07 LSB first
+		{IRCommand{Protocol: "Samsung32", Address: 0x07, Command: 0x02}, []int{4500, 4500, 560, 1690, 560, 1690, 560, 1690, 560, 560}, 68 * 2},
+		// header, vendor 0x2002 LSB first
+		{IRCommand{Protocol: "Panasonic", Address: 0x080, Command: 0x3d}, []int{3456, 1728, 432, 432, 432, 1296, 432, 432}, 100 * 2},
+		{IRCommand{Protocol: "Kaseikyo_Denon", Address: 0x123, Command: 0x45}, []int{3456, 1728, 432, 432, 432, 432, 432, 1296}, 100 * 2},
+		{IRCommand{Protocol: "Kaseikyo", Address: 0x5aaa0123, Command: 0x45}, []int{3456, 1728, 432, 432, 432, 1296}, 100 * 2},
+		// headerless repeat frame
+		{IRCommand{Protocol: "JVC", Address: 0x03, Command: 0x17}, []int{8400, 4200, 525, 1575, 525, 1575, 525, 525}, 36 + 34},
+		// address 0x01, then inverted second frame
+		{IRCommand{Protocol: "Sharp", Address: 0x01, Command: 0xd2}, []int{264, 1848, 264, 792}, 32 * 4},
+	}
+
+	for _, tc := range tests {
+		code, err := EncodeIRCommand(tc.c, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulses, err := DecodeCode(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pulses) != tc.count {
+			t.Errorf("%v: unexpected pulse count %d", tc.c, len(pulses))
+		}
+		for i, p := range tc.pulses {
+			if !within(pulses[i], p) {
+				t.Errorf("%v: pulses %v, expected %v", tc.c, pulses[:len(tc.pulses)], tc.pulses)
+				break
+			}
+		}
+		c, err := DecodeIRCommand(code)
+		if err != nil {
+			t.Errorf("%v: %v", tc.c, err)
+			continue
+		}
+		if c != tc.c {
+			t.Errorf("%v: decoded as %v", tc.c, c)
+		}
+	}
+
+	if _, err := EncodeIRCommand(IRCommand{Protocol: "Panasonic", Address: 0x1000}, 0); err == nil {
+		t.Error("13-bit Panasonic address accepted")
+	}
+}
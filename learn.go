@@ -0,0 +1,79 @@
+package broadlink
+
+import (
+	"context"
+	"time"
+)
+
+var (
+	defaultPollInterval = 500 * time.Millisecond
+
+	// Default interval to re-enter capture mode while learning. RM devices leave capture mode silently after about 30 seconds.
+	defaultRearmInterval = 20 * time.Second
+)
+
+// Capture operations of a device.
+type codeCapturer interface {
+	StartCaptureRemoteControlCode() error
+	ReadCapturedRemoteControlCode() (RemoteType, []byte, error)
+}
+
+// get polling interval of captured codes
+func (d *Device) pollInterval() time.Duration {
+	if d.PollInterval > 0 {
+		return d.PollInterval
+	}
+	return defaultPollInterval
+}
+
+// get re-arming interval of capture mode. Zero for never
+func (d *Device) rearmInterval() time.Duration {
+	switch {
+	case d.RearmInterval > 0:
+		return d.RearmInterval
+	case d.RearmInterval < 0:
+		return 0
+	}
+	return defaultRearmInterval
+}
+
+// Learn a remote control code. The device enters capture mode and is polled every d.PollInterval until a signal is captured.
+// The device leaves capture mode silently after about 30 seconds and reports nothing about it, so capture mode is re-entered at a fixed period of d.RearmInterval.
+// A button pressed just as capture mode is re-entered may be lost. Press it again, or set a negative d.RearmInterval to capture within a single arming.
+// Use a context with a deadline for a timeout. If ctx is done before a signal is captured, err is ctx.Err().
+func (d *Device) LearnCode(ctx context.Context) (rtype RemoteType, code []byte, err error) {
+	return learnCode(ctx, d, d.pollInterval(), d.rearmInterval())
+}
+
+// learn a code with a capturer. A zero rearm never re-enters capture mode
+func learnCode(ctx context.Context, c codeCapturer, interval, rearm time.Duration) (rtype RemoteType, code []byte, err error) {
+	if err = ctx.Err(); err != nil {
+		return
+	}
+	if err = c.StartCaptureRemoteControlCode(); err != nil {
+		return
+	}
+	armed := time.Now()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		case <-ticker.C:
+		}
+
+		rtype, code, err = c.ReadCapturedRemoteControlCode()
+		if err != ErrNotCaptured {
+			return
+		}
+		if rearm > 0 && time.Since(armed) >= rearm {
+			if err = c.StartCaptureRemoteControlCode(); err != nil {
+				return
+			}
+			armed = time.Now()
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package broadlink
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// a capturer returning a code after some reads
+type fakeCapturer struct {
+	starts, reads int
+	captureAt     int // read count to return a code. zero for never
+	failAt        int // read count to return an error. zero for never
+}
+
+func (c *fakeCapturer) StartCaptureRemoteControlCode() error {
+	c.starts++
+	return nil
+}
+
+func (c *fakeCapturer) ReadCapturedRemoteControlCode() (RemoteType, []byte, error) {
+	c.reads++
+	switch c.reads {
+	case c.captureAt:
+		return REMOTE_IR, []byte{1, 2, 3}, nil
+	case c.failAt:
+		return 0, nil, fmt.Errorf("failed reading remote control code")
+	}
+	return 0, nil, ErrNotCaptured
+}
+
+func TestLearnCode(t *testing.T) {
+	ctx := context.Background()
+
+	c := &fakeCapturer{captureAt: 5}
+	rtype, code, err := learnCode(ctx, c, time.Millisecond, 2*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rtype != REMOTE_IR || !bytes.Equal(code, []byte{1, 2, 3}) || c.reads != 5 {
+		t.Errorf("unexpected result %v %v after %d reads", rtype, code, c.reads)
+	}
+	if c.starts < 2 {
+		t.Errorf("capture mode is not re-entered")
+	}
+
+	c = &fakeCapturer{failAt: 2}
+	if _, _, err = learnCode(ctx, c, time.Millisecond, time.Hour); err == nil || err == ErrNotCaptured {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// cancellation
+	c = &fakeCapturer{}
+	tctx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, err = learnCode(tctx, c, time.Millisecond, time.Hour); err != context.DeadlineExceeded {
+		t.Errorf("unexpected error %v", err)
+	}
+	if c.starts != 1 {
+		t.Errorf("capture mode is entered %d times", c.starts)
+	}
+
+	// already cancelled
+	c = &fakeCapturer{}
+	cctx, cancel2 := context.WithCancel(ctx)
+	cancel2()
+	if _, _, err = learnCode(cctx, c, time.Millisecond, time.Hour); err != context.Canceled {
+		t.Errorf("unexpected error %v", err)
+	}
+	if c.starts != 0 {
+		t.Errorf("capture mode is entered on a cancelled context")
+	}
+}
@@ -0,0 +1,133 @@
+package broadlink
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	defaultVerifyCaptures = 3
+)
+
+var (
+	ErrNotVerified = fmt.Errorf("captured codes do not match") // Not enough matching captures within the attempts
+)
+
+// Options of LearnVerifiedCode().
+type VerifyOptions struct {
+	Captures    int          // number of matching captures required. Zero for 3
+	MaxAttempts int          // maximum number of captures. Zero for twice of Captures
+	Match       MatchOptions // options to compare captures. See CompareCodes()
+
+	// Called before each capture to ask for a button press, with the attempt number from 1 and the number of captures matching so far. May be nil.
+	Prompt func(attempt, matched int)
+}
+
+// Result of LearnVerifiedCode().
+type VerifiedCode struct {
+	Type     RemoteType
+	Code     []byte  // consensus code averaged from the matching captures
+	Quality  float64 // from 0 to 1. Mean similarity of the matching captures to the consensus code. See CompareCodes() for scores
+	Matched  int     // number of captures used for the consensus
+	Rejected int     // number of captures rejected as outliers
+}
+
+// Learn a code by capturing a button several times. Captures not matching the others are rejected, and more presses are asked until enough captures match.
+// The matching captures are averaged into a consensus code. If the captures do not match within opt.MaxAttempts, err is ErrNotVerified.
+func (d *Device) LearnVerifiedCode(ctx context.Context, opt VerifyOptions) (vc VerifiedCode, err error) {
+	return learnVerified(ctx, d.LearnCode, opt)
+}
+
+// a captured code
+type capture struct {
+	rtype  RemoteType
+	pulses []int
+}
+
+// learn a verified code with a learning function
+func learnVerified(ctx context.Context, learn func(ctx context.Context) (RemoteType, []byte, error), opt VerifyOptions) (vc VerifiedCode, err error) {
+	need := opt.Captures
+	if need <= 0 {
+		need = defaultVerifyCaptures
+	}
+	attempts := opt.MaxAttempts
+	if attempts <= 0 {
+		attempts = need * 2
+	}
+
+	var captures []capture
+	matched := 0
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if opt.Prompt != nil {
+			opt.Prompt(attempt, matched)
+		}
+		rtype, code, e := learn(ctx)
+		if e != nil {
+			err = e
+			return
+		}
+		pulses, e := DecodeCode(code)
+		if e != nil {
+			continue // a broken capture counts as an attempt
+		}
+		captures = append(captures, capture{rtype, pulses})
+
+		group := largestMatchingGroup(captures, opt.Match)
+		matched = len(group)
+		if matched < need {
+			continue
+		}
+
+		// consensus of the group
+		var members []capture
+		for _, i := range group {
+			members = append(members, captures[i])
+		}
+		consensus := averagePulses(members)
+		for _, m := range members {
+			s, _ := comparePulses(consensus, m.pulses, opt.Match)
+			vc.Quality += s
+		}
+		vc.Quality /= float64(len(members))
+		vc.Type, vc.Code = members[0].rtype, EncodeCode(consensus)
+		vc.Matched, vc.Rejected = len(members), attempt-len(members)
+		return
+	}
+	err = ErrNotVerified
+	return
+}
+
+// find the largest group of captures matching the same capture
+func largestMatchingGroup(captures []capture, opt MatchOptions) (group []int) {
+	for i, a := range captures {
+		g := []int{i}
+		for j, b := range captures {
+			if i == j || a.rtype != b.rtype {
+				continue
+			}
+			if _, same := comparePulses(a.pulses, b.pulses, opt); same {
+				g = append(g, j)
+			}
+		}
+		if len(g) > len(group) {
+			group = g
+		}
+	}
+	return
+}
+
+// average durations of captures. The result has the length of the first capture, and missing durations of others are skipped.
+func averagePulses(captures []capture) []int {
+	avg := make([]int, len(captures[0].pulses))
+	for i := range avg {
+		sum, n := 0, 0
+		for _, c := range captures {
+			if i < len(c.pulses) {
+				sum += c.pulses[i]
+				n++
+			}
+		}
+		avg[i] = (sum + n/2) / n
+	}
+	return avg
+}
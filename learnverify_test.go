@@ -0,0 +1,56 @@
+package broadlink
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLearnVerified(t *testing.T) {
+
+	jittered := func(c IRCommand, jitter int) []byte {
+		code, err := EncodeIRCommand(c, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulses, _ := DecodeCode(code)
+		for i := range pulses {
+			if i%2 == 0 {
+				pulses[i] += jitter
+			} else {
+				pulses[i] -= jitter
+			}
+		}
+		return EncodeCode(pulses)
+	}
+	power := IRCommand{Protocol: "NEC", Address: 4, Command: 8}
+	mute := IRCommand{Protocol: "NEC", Address: 4, Command: 9}
+	codes := [][]byte{jittered(power, 60), jittered(mute, 0), jittered(power, -40), jittered(power, 20), jittered(power, 0)}
+
+	n := 0
+	learn := func(ctx context.Context) (RemoteType, []byte, error) {
+		n++
+		return REMOTE_IR, codes[n-1], nil
+	}
+	prompts := 0
+	vc, err := learnVerified(context.Background(), learn, VerifyOptions{Prompt: func(attempt, matched int) { prompts++ }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 4 || prompts != 4 || vc.Matched != 3 || vc.Rejected != 1 {
+		t.Errorf("unexpected result after %d captures: %+v", n, vc)
+	}
+	if vc.Quality < 0.75 || vc.Quality > 1 {
+		t.Errorf("unexpected quality %g", vc.Quality)
+	}
+	c, err := DecodeIRCommand(vc.Code)
+	if err != nil || c != power {
+		t.Errorf("unexpected consensus code %v %v", c, err)
+	}
+
+	// no consensus within the attempts
+	n = 0
+	codes = [][]byte{jittered(power, 0), jittered(mute, 0), jittered(IRCommand{Protocol: "NEC", Address: 1, Command: 1}, 0)}
+	if _, err = learnVerified(context.Background(), learn, VerifyOptions{Captures: 2, MaxAttempts: 3}); err != ErrNotVerified {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,452 @@
+package broadlink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Version of the library file format written by Library.Save().
+const LibraryVersion = 1
+
+// Names of remote types in library files
+var remoteTypeNames = map[RemoteType]string{
+	REMOTE_IR:       "IR",
+	REMOTE_RF433Mhz: "RF433",
+	REMOTE_RF315Mhz: "RF315",
+}
+
+// A library of named remotes and buttons, stored as a versioned JSON file, or as YAML with a LibraryEncoding.
+// A Library is not safe for concurrent use.
+type Library struct {
+	Version int      `json:"version"`
+	Remotes []Remote `json:"remotes"`
+
+	devices map[string]codeSender // devices by MAC address
+}
+
+// Encoding of library files other than JSON, such as YAML. The package has no YAML encoder, so supply one with an adapter like
+//
+//	type yamlEncoding struct{}
+//
+//	func (yamlEncoding) Marshal(v interface{}) ([]byte, error)      { return yaml.Marshal(v) }
+//	func (yamlEncoding) Unmarshal(data []byte, v interface{}) error { return yaml.Unmarshal(data, v) }
+//
+// The encoding gets and returns the JSON form of the library as maps, slices and plain values, so field names are the same as JSON.
+type LibraryEncoding interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Encodings of library files by file name extension, such as LibraryEncodings[".yaml"] = yamlEncoding{}.
+// Files of other extensions are JSON.
+var LibraryEncodings = map[string]LibraryEncoding{}
+
+// Sending operation of a device.
+type codeSender interface {
+	SendRemoteControlCode(rtype RemoteType, code []byte, count int) error
+}
+
+// Remote type in library files, named "IR", "RF433" or "RF315". RemoteType itself is kept a plain number.
+type libraryRemoteType RemoteType
+
+// get the name of a remote type. Unknown types are formatted in hex
+func remoteTypeName(t RemoteType) string {
+	if s, ok := remoteTypeNames[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("0x%02x", int(t))
+}
+
+func (t libraryRemoteType) MarshalText() ([]byte, error) {
+	return []byte(remoteTypeName(RemoteType(t))), nil
+}
+
+func (t *libraryRemoteType) UnmarshalText(text []byte) error {
+	for rt, s := range remoteTypeNames {
+		if strings.EqualFold(s, string(text)) {
+			*t = libraryRemoteType(rt)
+			return nil
+		}
+	}
+	v, err := strconv.ParseInt(string(text), 0, 16)
+	if err != nil {
+		return fmt.Errorf("unknown remote type %q", text)
+	}
+	*t = libraryRemoteType(v)
+	return nil
+}
+
+// Marshal a button of a library file, with the type by name.
+func (b Button) MarshalJSON() ([]byte, error) {
+	type button Button // without methods
+	return json.Marshal(struct {
+		button
+		Type libraryRemoteType `json:"type"`
+	}{button(b), libraryRemoteType(b.Type)})
+}
+
+// Unmarshal a button of a library file.
+func (b *Button) UnmarshalJSON(data []byte) (err error) {
+	type button Button
+	v := struct {
+		*button
+		Type libraryRemoteType `json:"type"`
+	}{button: (*button)(b)}
+	if err = json.Unmarshal(data, &v); err != nil {
+		return
+	}
+	b.Type = RemoteType(v.Type)
+	return
+}
+
+// Make an empty library.
+func NewLibrary() *Library {
+	return &Library{Version: LibraryVersion}
+}
+
+// Load a library from JSON.
+func LoadLibrary(r io.Reader) (l *Library, err error) {
+	l = &Library{}
+	if err = json.NewDecoder(r).Decode(l); err != nil {
+		l = nil
+		return
+	}
+	if l.Version < 1 || l.Version > LibraryVersion {
+		err = fmt.Errorf("unsupported library version %d", l.Version)
+		l = nil
+	}
+	return
+}
+
+// Load a library with an encoding other than JSON.
+func LoadLibraryEncoded(r io.Reader, enc LibraryEncoding) (l *Library, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return
+	}
+	var v interface{}
+	if err = enc.Unmarshal(data, &v); err != nil {
+		return
+	}
+	if data, err = json.Marshal(jsonValue(v)); err != nil {
+		return
+	}
+	return LoadLibrary(bytes.NewReader(data))
+}
+
+// Load a library from a file. The encoding is chosen by the extension with LibraryEncodings.
+func LoadLibraryFile(path string) (l *Library, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if enc, ok := LibraryEncodings[strings.ToLower(filepath.Ext(path))]; ok {
+		return LoadLibraryEncoded(f, enc)
+	}
+	return LoadLibrary(f)
+}
+
+// Save the library as JSON.
+func (l *Library) Save(w io.Writer) error {
+	l.Version = LibraryVersion
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+// Save the library with an encoding other than JSON.
+func (l *Library) SaveEncoded(w io.Writer, enc LibraryEncoding) (err error) {
+	l.Version = LibraryVersion
+	data, err := json.Marshal(l)
+	if err != nil {
+		return
+	}
+	var v interface{}
+	if err = json.Unmarshal(data, &v); err != nil {
+		return
+	}
+	if data, err = enc.Marshal(v); err != nil {
+		return
+	}
+	_, err = w.Write(data)
+	return
+}
+
+// convert a decoded value to one encoding/json can marshal. Some YAML decoders make maps of interface{} keys
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = jsonValue(e)
+		}
+		return m
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonValue(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonValue(e)
+		}
+	}
+	return v
+}
+
+// Save the library to a file. The encoding is chosen by the extension with LibraryEncodings.
+// The file is replaced at once so that a failure never leaves a broken file.
+// The permissions of an existing file are kept, and a new file is made with 0644.
+func (l *Library) SaveFile(path string) (err error) {
+	mode := os.FileMode(0644)
+	if fi, e := os.Stat(path); e == nil {
+		mode = fi.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+	if err = f.Chmod(mode); err != nil {
+		f.Close()
+		return
+	}
+	if enc, ok := LibraryEncodings[strings.ToLower(filepath.Ext(path))]; ok {
+		err = l.SaveEncoded(f, enc)
+	} else {
+		err = l.Save(f)
+	}
+	if err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Get names of the remotes.
+func (l *Library) RemoteNames() (names []string) {
+	for _, rm := range l.Remotes {
+		names = append(names, rm.Name)
+	}
+	return
+}
+
+// get the index of a remote
+func (l *Library) remoteIndex(name string) int {
+	for i, rm := range l.Remotes {
+		if rm.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get a remote by name. The returned pointer is valid until remotes are added or deleted.
+func (l *Library) Remote(name string) (rm *Remote, err error) {
+	i := l.remoteIndex(name)
+	if i < 0 {
+		err = fmt.Errorf("remote %s not found", name)
+		return
+	}
+	rm = &l.Remotes[i]
+	return
+}
+
+// Add a remote. The name must be unique, and the buttons are checked as by SetButton().
+func (l *Library) AddRemote(rm Remote) (err error) {
+	if rm.Name == "" {
+		err = fmt.Errorf("empty remote name")
+		return
+	}
+	if err = rm.checkButtons(); err != nil {
+		return
+	}
+	if l.remoteIndex(rm.Name) >= 0 {
+		err = fmt.Errorf("remote %s already exists", rm.Name)
+		return
+	}
+	l.Remotes = append(l.Remotes, rm)
+	return
+}
+
+// Replace a remote of the same name. The buttons are checked as by SetButton().
+func (l *Library) UpdateRemote(rm Remote) (err error) {
+	if err = rm.checkButtons(); err != nil {
+		return
+	}
+	i := l.remoteIndex(rm.Name)
+	if i < 0 {
+		err = fmt.Errorf("remote %s not found", rm.Name)
+		return
+	}
+	l.Remotes[i] = rm
+	return
+}
+
+// Rename a remote.
+func (l *Library) RenameRemote(name, newName string) (err error) {
+	if newName == "" {
+		err = fmt.Errorf("empty remote name")
+		return
+	}
+	if newName != name && l.remoteIndex(newName) >= 0 {
+		err = fmt.Errorf("remote %s already exists", newName)
+		return
+	}
+	rm, err := l.Remote(name)
+	if err != nil {
+		return
+	}
+	rm.Name = newName
+	return
+}
+
+// Delete a remote.
+func (l *Library) DeleteRemote(name string) (err error) {
+	i := l.remoteIndex(name)
+	if i < 0 {
+		err = fmt.Errorf("remote %s not found", name)
+		return
+	}
+	l.Remotes = append(l.Remotes[:i], l.Remotes[i+1:]...)
+	return
+}
+
+// get the index of a button
+func (rm *Remote) buttonIndex(name string) int {
+	for i, b := range rm.Buttons {
+		if b.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// check buttons of a remote, rejecting duplicate names
+func (rm *Remote) checkButtons() (err error) {
+	names := map[string]bool{}
+	for _, b := range rm.Buttons {
+		if err = checkButton(b); err != nil {
+			return
+		}
+		if names[b.Name] {
+			err = fmt.Errorf("duplicate button %s of remote %s", b.Name, rm.Name)
+			return
+		}
+		names[b.Name] = true
+	}
+	return
+}
+
+// check a button to be stored in a library
+func checkButton(b Button) (err error) {
+	if b.Name == "" {
+		err = fmt.Errorf("empty button name")
+		return
+	}
+	if _, ok := remoteTypeNames[b.Type]; !ok {
+		err = fmt.Errorf("unknown remote type %02x", int(b.Type))
+		return
+	}
+	if len(b.Code) == 0 {
+		err = fmt.Errorf("empty code of button %s", b.Name)
+	}
+	return
+}
+
+// Get a button of a remote.
+func (l *Library) Button(remote, button string) (b *Button, err error) {
+	rm, err := l.Remote(remote)
+	if err != nil {
+		return
+	}
+	i := rm.buttonIndex(button)
+	if i < 0 {
+		err = fmt.Errorf("button %s of remote %s not found", button, remote)
+		return
+	}
+	b = &rm.Buttons[i]
+	return
+}
+
+// Add a button to a remote, or replace the button of the same name.
+func (l *Library) SetButton(remote string, b Button) (err error) {
+	if err = checkButton(b); err != nil {
+		return
+	}
+	rm, err := l.Remote(remote)
+	if err != nil {
+		return
+	}
+	if i := rm.buttonIndex(b.Name); i >= 0 {
+		rm.Buttons[i] = b
+	} else {
+		rm.Buttons = append(rm.Buttons, b)
+	}
+	return
+}
+
+// Delete a button of a remote.
+func (l *Library) DeleteButton(remote, button string) (err error) {
+	rm, err := l.Remote(remote)
+	if err != nil {
+		return
+	}
+	i := rm.buttonIndex(button)
+	if i < 0 {
+		err = fmt.Errorf("button %s of remote %s not found", button, remote)
+		return
+	}
+	rm.Buttons = append(rm.Buttons[:i], rm.Buttons[i+1:]...)
+	return
+}
+
+// Register a device to send codes of remotes assigned to its MAC address.
+func (l *Library) SetDevice(d *Device) {
+	l.setSender(net.HardwareAddr(d.MACAddr).String(), d)
+}
+
+// register a sender by MAC address
+func (l *Library) setSender(mac string, s codeSender) {
+	if l.devices == nil {
+		l.devices = map[string]codeSender{}
+	}
+	l.devices[strings.ToLower(mac)] = s
+}
+
+// Send a button of a remote with the device assigned to the remote, repeating it by the default repeat count of the button.
+// The device must be registered with SetDevice().
+func (l *Library) Send(remote, button string) (err error) {
+	rm, err := l.Remote(remote)
+	if err != nil {
+		return
+	}
+	b, err := l.Button(remote, button)
+	if err != nil {
+		return
+	}
+	if rm.Device == "" {
+		err = fmt.Errorf("remote %s has no device", remote)
+		return
+	}
+	d, ok := l.devices[strings.ToLower(rm.Device)]
+	if !ok {
+		err = fmt.Errorf("device %s of remote %s is not registered", rm.Device, remote)
+		return
+	}
+	return d.SendRemoteControlCode(b.Type, b.Code, b.Repeat+1)
+}
@@ -0,0 +1,184 @@
+package broadlink
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fakeSender struct {
+	rtype RemoteType
+	code  []byte
+	count int
+}
+
+func (f *fakeSender) SendRemoteControlCode(rtype RemoteType, code []byte, count int) error {
+	f.rtype, f.code, f.count = rtype, code, count
+	return nil
+}
+
+// encoding to test LibraryEncoding. It decodes maps with interface{} keys as some YAML decoders do
+type fakeEncoding struct{}
+
+func (fakeEncoding) Marshal(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	return append([]byte("fake:"), data...), err
+}
+
+func (fakeEncoding) Unmarshal(data []byte, v interface{}) error {
+	if !bytes.HasPrefix(data, []byte("fake:")) {
+		return fmt.Errorf("not fake")
+	}
+	if err := json.Unmarshal(data[5:], v); err != nil {
+		return err
+	}
+	*v.(*interface{}) = anyKeys(*v.(*interface{}))
+	return nil
+}
+
+func anyKeys(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := map[interface{}]interface{}{}
+		for k, e := range v {
+			m[k] = anyKeys(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range v {
+			v[i] = anyKeys(e)
+		}
+	}
+	return v
+}
+
+func TestLibrary(t *testing.T) {
+
+	power, err := EncodeIRCommand(IRCommand{Protocol: "NEC", Address: 4, Command: 8}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := NewLibrary()
+	if err := l.AddRemote(Remote{Name: "tv", Device: "34:EA:34:01:02:03"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddRemote(Remote{Name: "tv"}); err == nil {
+		t.Error("duplicate remote added")
+	}
+	if err := l.SetButton("tv", Button{Name: "Power", Type: REMOTE_IR, Code: power, Notes: "toggle"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetButton("tv", Button{Name: "Power", Type: REMOTE_IR, Code: power, Repeat: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetButton("tv", Button{Name: "Mute", Type: REMOTE_RF433Mhz, Code: power}); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SetButton("tv", Button{Name: "Bad", Type: REMOTE_IR, Code: nil}); err == nil {
+		t.Error("empty code accepted")
+	}
+	if err := l.AddRemote(Remote{Name: "radio", Buttons: []Button{{Name: "On", Type: REMOTE_IR, Code: power}, {Name: "On", Type: REMOTE_IR, Code: power}}}); err == nil {
+		t.Error("duplicate button added")
+	}
+	if err := l.AddRemote(Remote{Name: "radio", Buttons: []Button{{Name: "On", Type: 0x99, Code: power}}}); err == nil {
+		t.Error("unknown remote type added")
+	}
+	if err := l.UpdateRemote(Remote{Name: "tv", Buttons: []Button{{Name: "", Type: REMOTE_IR, Code: power}}}); err == nil {
+		t.Error("empty button name updated")
+	}
+	if err := l.UpdateRemote(Remote{Name: "tv", Device: "34:EA:34:01:02:03", Buttons: []Button{{Name: "Power", Type: REMOTE_IR}}}); err == nil {
+		t.Error("empty code updated")
+	}
+	if rm, _ := l.Remote("tv"); len(rm.Buttons) != 2 || rm.Buttons[0].Repeat != 2 {
+		t.Errorf("buttons: %+v", rm.Buttons)
+	}
+
+	// save and load
+	var buf bytes.Buffer
+	if err := l.Save(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"type": "RF433"`) {
+		t.Errorf("saved: %s", buf.String())
+	}
+	if j, _ := json.Marshal(REMOTE_IR); string(j) != "38" {
+		t.Errorf("RemoteType marshaled as %s", j)
+	}
+	path := filepath.Join(t.TempDir(), "remotes.json")
+	if err := l.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if fi.Mode().Perm() != 0644 {
+		t.Errorf("new file mode %v", fi.Mode())
+	}
+	if err := os.Chmod(path, 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.SaveFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if fi.Mode().Perm() != 0640 {
+		t.Errorf("saved file mode %v", fi.Mode())
+	}
+	l, err = LoadLibraryFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := l.Button("tv", "Mute")
+	if err != nil || b.Type != REMOTE_RF433Mhz || !bytes.Equal(b.Code, power) {
+		t.Errorf("loaded: %+v %v", b, err)
+	}
+	buf.Reset()
+	if err := l.SaveEncoded(&buf, fakeEncoding{}); err != nil {
+		t.Fatal(err)
+	}
+	if le, err := LoadLibraryEncoded(&buf, fakeEncoding{}); err != nil {
+		t.Fatal(err)
+	} else if b, err := le.Button("tv", "Mute"); err != nil || b.Type != REMOTE_RF433Mhz || !bytes.Equal(b.Code, power) {
+		t.Errorf("loaded encoded: %+v %v", b, err)
+	}
+	if _, err := LoadLibrary(strings.NewReader(`{"version": 99}`)); err == nil {
+		t.Error("unsupported version loaded")
+	}
+
+	// send
+	if err := l.Send("tv", "Power"); err == nil {
+		t.Error("sent without a device")
+	}
+	f := &fakeSender{}
+	l.setSender("34:ea:34:01:02:03", f)
+	if err := l.Send("tv", "Power"); err != nil {
+		t.Fatal(err)
+	}
+	if f.rtype != REMOTE_IR || f.count != 3 || !bytes.Equal(f.code, power) {
+		t.Errorf("sent: %+v", f)
+	}
+	if err := l.Send("tv", "Volume Up"); err == nil {
+		t.Error("sent a missing button")
+	}
+
+	// rename and delete
+	if err := l.RenameRemote("tv", "living room tv"); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.DeleteButton("living room tv", "Power"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Button("living room tv", "Power"); err == nil {
+		t.Error("deleted button found")
+	}
+	if err := l.DeleteRemote("living room tv"); err != nil {
+		t.Fatal(err)
+	}
+	if names := l.RemoteNames(); len(names) != 0 {
+		t.Errorf("remotes: %v", names)
+	}
+}
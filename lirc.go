@@ -0,0 +1,383 @@
+package broadlink
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A remote defined in a LIRC configuration file (lircd.conf).
+type LIRCRemote struct {
+	Name      string       // name of the remote
+	Frequency int          // carrier frequency in Hz. zero if not given
+	DutyCycle float64      // carrier duty cycle from 0 to 1. zero if not given
+	Buttons   []LIRCButton // buttons of the remote
+}
+
+// A button of a LIRC remote.
+type LIRCButton struct {
+	Name string // button name, e.g. "KEY_POWER"
+	Code []byte // BroadLink IR code for SendIRRemoteCode()
+}
+
+// LIRC remote definition being parsed.
+type lircDef struct {
+	name  string
+	flags map[string]bool
+	attr  map[string][]uint64
+
+	codes    []lircNamedValues // protocol-described codes
+	rawCodes []lircNamedValues // raw codes
+}
+
+// A button name and its values in a LIRC codes section.
+type lircNamedValues struct {
+	name   string
+	values []uint64
+}
+
+// get the first value of an attribute
+func (def *lircDef) value(key string) int {
+	if v := def.attr[key]; len(v) > 0 {
+		return int(v[0])
+	}
+	return 0
+}
+
+// get a pulse/space pair attribute
+func (def *lircDef) pair(key string) (pulse, space int) {
+	v := def.attr[key]
+	if len(v) > 0 {
+		pulse = int(v[0])
+	}
+	if len(v) > 1 {
+		space = int(v[1])
+	}
+	return
+}
+
+// Parse a LIRC configuration file (lircd.conf) and build BroadLink IR codes for each button.
+// Both raw_codes remotes and protocol-described remotes of space encoding (SPACE_ENC), RC5/SHIFT_ENC and RC6 are supported.
+// Carriers are not checked. Call CheckCarrier() with the frequency of a remote before sending its codes with a BroadLink device.
+func ParseLIRCConfig(r io.Reader) (remotes []LIRCRemote, err error) {
+
+	var def *lircDef
+	section := "" // "", "codes" or "raw_codes"
+	var raw *lircNamedValues
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		key := strings.ToLower(fields[0])
+
+		if key == "begin" || key == "end" {
+			if len(fields) < 2 {
+				err = fmt.Errorf("line %d: missing block name", lineNo)
+				return
+			}
+			block := strings.ToLower(fields[1])
+			switch {
+			case key == "begin" && block == "remote":
+				def = &lircDef{flags: map[string]bool{}, attr: map[string][]uint64{}}
+			case key == "end" && block == "remote":
+				if def == nil {
+					err = fmt.Errorf("line %d: unexpected end of remote", lineNo)
+					return
+				}
+				rm, e := def.build()
+				if e != nil {
+					err = fmt.Errorf("remote %s: %v", def.name, e)
+					return
+				}
+				remotes = append(remotes, rm)
+				def = nil
+			case key == "begin" && (block == "codes" || block == "raw_codes"):
+				if def == nil {
+					err = fmt.Errorf("line %d: codes outside of a remote", lineNo)
+					return
+				}
+				section = block
+			case key == "end" && (block == "codes" || block == "raw_codes"):
+				section, raw = "", nil
+			default:
+				err = fmt.Errorf("line %d: unknown block %s", lineNo, fields[1])
+				return
+			}
+			continue
+		}
+		if def == nil {
+			continue
+		}
+
+		switch section {
+		case "codes":
+			b := lircNamedValues{name: fields[0]}
+			b.values, err = parseLIRCNumbers(fields[1:])
+			if err != nil {
+				err = fmt.Errorf("line %d: %v", lineNo, err)
+				return
+			}
+			def.codes = append(def.codes, b)
+
+		case "raw_codes":
+			if key == "name" {
+				if len(fields) < 2 {
+					err = fmt.Errorf("line %d: missing button name", lineNo)
+					return
+				}
+				def.rawCodes = append(def.rawCodes, lircNamedValues{name: fields[1]})
+				raw = &def.rawCodes[len(def.rawCodes)-1]
+				fields = fields[2:]
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			if raw == nil {
+				err = fmt.Errorf("line %d: raw code without a name", lineNo)
+				return
+			}
+			v, e := parseLIRCNumbers(fields)
+			if e != nil {
+				err = fmt.Errorf("line %d: %v", lineNo, e)
+				return
+			}
+			raw.values = append(raw.values, v...)
+
+		default:
+			switch key {
+			case "name":
+				if len(fields) > 1 {
+					def.name = fields[1]
+				}
+			case "flags":
+				for _, f := range strings.Split(strings.Join(fields[1:], ""), "|") {
+					def.flags[strings.ToUpper(f)] = true
+				}
+			default:
+				v, e := parseLIRCNumbers(fields[1:])
+				if e != nil {
+					// ignore non-numeric attributes such as driver or serial_mode
+					continue
+				}
+				def.attr[key] = v
+			}
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	if def != nil {
+		err = fmt.Errorf("unterminated remote %s", def.name)
+	}
+	return
+}
+
+// parse decimal or hexadecimal numbers
+func parseLIRCNumbers(fields []string) (values []uint64, err error) {
+	values = make([]uint64, len(fields))
+	for i, f := range fields {
+		values[i], err = strconv.ParseUint(f, 0, 64)
+		if err != nil {
+			err = fmt.Errorf("invalid number %q", f)
+			return
+		}
+	}
+	return
+}
+
+// build BroadLink codes of a parsed remote
+func (def *lircDef) build() (rm LIRCRemote, err error) {
+	rm.Name = def.name
+	rm.Frequency = def.value("frequency")
+	rm.DutyCycle = float64(def.value("duty_cycle")) / 100 // percent
+	gap := def.value("gap")
+	if gap == 0 {
+		gap = defaultTrailingGap
+	}
+
+	for _, rc := range def.rawCodes {
+		var b pulseBuilder
+		for i, v := range rc.values {
+			if i%2 == 0 {
+				b.mark(int(v))
+			} else {
+				b.space(int(v))
+			}
+		}
+		if len(b.pulses) == 0 {
+			err = fmt.Errorf("empty raw code %s", rc.name)
+			return
+		}
+		b.space(gap)
+		rm.Buttons = append(rm.Buttons, LIRCButton{Name: rc.name, Code: EncodeCode(b.pulses)})
+	}
+
+	for _, c := range def.codes {
+		var b pulseBuilder
+		for _, v := range c.values {
+			err = def.frame(&b, v)
+			if err != nil {
+				return
+			}
+		}
+		if len(b.pulses) == 0 {
+			err = fmt.Errorf("empty code %s", c.name)
+			return
+		}
+		rm.Buttons = append(rm.Buttons, LIRCButton{Name: c.name, Code: EncodeCode(b.pulses)})
+	}
+	return
+}
+
+// render a frame of a protocol-described remote
+func (def *lircDef) frame(b *pulseBuilder, code uint64) (err error) {
+
+	var biphase, rc6 bool
+	for f := range def.flags {
+		switch f {
+		case "RC5", "SHIFT_ENC":
+			biphase = true
+		case "RC6":
+			biphase, rc6 = true, true
+		case "", "SPACE_ENC", "CONST_LENGTH", "REVERSE", "NO_HEAD_REP", "NO_FOOT_REP", "REPEAT_HEADER":
+		default:
+			return fmt.Errorf("unsupported flag %s", f)
+		}
+	}
+
+	start := b.duration()
+	rc6Mask := uint64(def.value("rc6_mask"))
+	totalBits := def.value("pre_data_bits") + def.value("bits") + def.value("post_data_bits")
+	bitIndex := 0 // bit index from the first bit of pre_data
+
+	sendBits := func(data uint64, bits int) {
+		if def.flags["REVERSE"] {
+			var r uint64
+			for i := 0; i < bits; i++ {
+				r = r<<1 | (data>>uint(i))&1
+			}
+			data = r
+		}
+		for i := bits - 1; i >= 0; i-- {
+			one := (data>>uint(i))&1 == 1
+			p, s := def.pair("zero")
+			if one {
+				p, s = def.pair("one")
+			}
+			if rc6Mask&(1<<uint(totalBits-1-bitIndex)) != 0 {
+				p, s = p*2, s*2
+			}
+			bitIndex++
+			switch {
+			case rc6 && !one, biphase && !rc6 && one:
+				b.space(s)
+				b.mark(p)
+			default:
+				b.mark(p)
+				b.space(s)
+			}
+		}
+	}
+	sendPair := func(key string) {
+		p, s := def.pair(key)
+		b.mark(p)
+		b.space(s)
+	}
+
+	sendPair("header")
+	b.mark(def.value("plead"))
+	sendBits(uint64(def.value("pre_data")), def.value("pre_data_bits"))
+	sendPair("pre")
+	sendBits(code, def.value("bits"))
+	sendPair("post")
+	sendBits(uint64(def.value("post_data")), def.value("post_data_bits"))
+	b.mark(def.value("ptrail"))
+	sendPair("foot")
+
+	gap := def.value("gap")
+	if gap == 0 {
+		gap = defaultTrailingGap
+	}
+	if def.flags["CONST_LENGTH"] {
+		gap -= b.duration() - start
+		if gap <= 0 {
+			gap = 1
+		}
+	}
+	b.space(gap)
+	return
+}
+
+// Write a remote as a LIRC configuration file (lircd.conf) of raw_codes format.
+func WriteLIRCConfig(w io.Writer, rm LIRCRemote) (err error) {
+
+	type rawButton struct {
+		name   string
+		pulses []int
+	}
+	buttons := make([]rawButton, len(rm.Buttons))
+	gap := 0
+	for i, bt := range rm.Buttons {
+		pulses, e := DecodeCode(bt.Code)
+		if e != nil {
+			err = fmt.Errorf("button %s: %v", bt.Name, e)
+			return
+		}
+		if len(pulses)%2 == 0 {
+			// LIRC raw codes end with a pulse. The trailing space goes to the gap.
+			if pulses[len(pulses)-1] > gap {
+				gap = pulses[len(pulses)-1]
+			}
+			pulses = pulses[:len(pulses)-1]
+		}
+		buttons[i] = rawButton{name: bt.Name, pulses: pulses}
+	}
+	if gap == 0 {
+		gap = defaultTrailingGap
+	}
+
+	bw := bufio.NewWriter(w)
+	name := rm.Name
+	if name == "" {
+		name = "broadlink"
+	}
+	fmt.Fprintf(bw, "begin remote\n\n")
+	fmt.Fprintf(bw, "  name  %s\n", name)
+	fmt.Fprintf(bw, "  flags RAW_CODES\n")
+	fmt.Fprintf(bw, "  eps            30\n")
+	fmt.Fprintf(bw, "  aeps          100\n")
+	if rm.Frequency > 0 {
+		fmt.Fprintf(bw, "  frequency    %d\n", rm.Frequency)
+	}
+	if rm.DutyCycle > 0 {
+		fmt.Fprintf(bw, "  duty_cycle   %.0f\n", rm.DutyCycle*100)
+	}
+	fmt.Fprintf(bw, "  gap          %d\n\n", gap)
+	fmt.Fprintf(bw, "      begin raw_codes\n")
+	for _, bt := range buttons {
+		fmt.Fprintf(bw, "\n          name %s\n", bt.name)
+		for i, p := range bt.pulses {
+			if i%6 == 0 {
+				if i > 0 {
+					fmt.Fprintln(bw)
+				}
+				fmt.Fprintf(bw, "            ")
+			}
+			fmt.Fprintf(bw, " %7d", p)
+		}
+		fmt.Fprintln(bw)
+	}
+	fmt.Fprintf(bw, "\n      end raw_codes\n\nend remote\n")
+	return bw.Flush()
+}
@@ -0,0 +1,118 @@
+package broadlink
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const testLIRCConfig = `
+# an NEC remote
+begin remote
+  name  LG_TV
+  bits           16
+  flags SPACE_ENC|CONST_LENGTH
+  eps            30
+  aeps          100
+  header       9000  4500
+  one           560  1690
+  zero          560   560
+  ptrail        560
+  pre_data_bits   16
+  pre_data       0x20DF
+  gap          108000
+  frequency    38000
+
+      begin codes
+          KEY_POWER                0x10EF   # power toggle
+          KEY_MUTE                 0x906F
+      end codes
+end remote
+
+begin remote
+  name  raw_remote
+  flags RAW_CODES
+  eps            30
+  aeps          100
+  gap          50000
+
+      begin raw_codes
+          name KEY_UP
+             900 900 1800 900
+             900
+          name KEY_DOWN  900 1800 900
+      end raw_codes
+end remote
+`
+
+func TestLIRC(t *testing.T) {
+
+	remotes, err := ParseLIRCConfig(strings.NewReader(testLIRCConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remotes) != 2 {
+		t.Fatalf("unexpected remote count %d", len(remotes))
+	}
+
+	tv := remotes[0]
+	if tv.Name != "LG_TV" || tv.Frequency != 38000 || len(tv.Buttons) != 2 || tv.Buttons[0].Name != "KEY_POWER" {
+		t.Fatalf("invalid remote %v", tv)
+	}
+	pulses, err := DecodeCode(tv.Buttons[0].Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// header + 32 bits + trailer + gap
+	if len(pulses) != 68 {
+		t.Fatalf("unexpected pulse count %d", len(pulses))
+	}
+	// 0x20DF10EF: first bits are 0, 0, 1
+	if !within(pulses[3], 560) || !within(pulses[5], 560) || !within(pulses[7], 1690) {
+		t.Errorf("invalid bit timings %v", pulses[:8])
+	}
+	total := 0
+	for _, p := range pulses {
+		total += p
+	}
+	if total < 107000 || total > 109000 {
+		t.Errorf("CONST_LENGTH frame length %d", total)
+	}
+
+	raw := remotes[1]
+	if len(raw.Buttons) != 2 || raw.Buttons[1].Name != "KEY_DOWN" {
+		t.Fatalf("invalid remote %v", raw)
+	}
+	pulses, err = DecodeCode(raw.Buttons[0].Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulses) != 6 || !within(pulses[2], 1800) || !within(pulses[5], 50000) {
+		t.Errorf("invalid raw code %v", pulses)
+	}
+
+	// export and import again
+	var buf bytes.Buffer
+	err = WriteLIRCConfig(&buf, tv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exported, err := ParseLIRCConfig(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(exported) != 1 || len(exported[0].Buttons) != 2 || exported[0].Frequency != 38000 {
+		t.Fatalf("invalid exported remote %v", exported)
+	}
+	for i, b := range exported[0].Buttons {
+		if b.Name != tv.Buttons[i].Name || !bytes.Equal(b.Code, tv.Buttons[i].Code) {
+			t.Errorf("button %s changed on export", b.Name)
+		}
+	}
+}
+
+// check a measured duration is within a tick from the expected value
+func within(measured, expected int) bool {
+	d := measured - expected
+	return -codeTickNs/1000 <= d && d <= codeTickNs/1000
+}
@@ -0,0 +1,360 @@
+package broadlink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMacroRetries = 2
+	maxSendCount        = 256 // largest count of a SendRemoteControlCode() call
+)
+
+var (
+	defaultMacroRetryDelay = time.Second
+)
+
+// Type of a macro step.
+type MacroStepType int
+
+const (
+	MACRO_SEND   MacroStepType = iota // send a remote control code
+	MACRO_DELAY                       // wait for a duration
+	MACRO_REPEAT                      // run a block of steps several times
+	MACRO_CALL                        // run another macro
+	MACRO_POWER                       // turn a power plug on or off
+)
+
+// What to do when a macro step fails.
+type ErrorPolicy int
+
+const (
+	ON_ERROR_ABORT    ErrorPolicy = iota // stop the macro with the error
+	ON_ERROR_CONTINUE                    // report the error and go to the next step
+	ON_ERROR_RETRY                       // retry the step, then stop the macro if it still fails
+)
+
+// A step of a macro. Make one with SendStep(), DelayStep(), RepeatStep(), CallStep() or PowerStep().
+type MacroStep struct {
+	Type    MacroStepType
+	Label   string      // description for progress reports. If empty, one is made from the step
+	OnError ErrorPolicy // policy on a failure of this step
+
+	Device string // MAC address of the device for MACRO_SEND and MACRO_POWER. Empty for the default device of the runner
+
+	RemoteType RemoteType // MACRO_SEND: signal type
+	Code       []byte     // MACRO_SEND: code for SendRemoteControlCode()
+	Count      int        // MACRO_SEND: number of sends, 1 for once. Counts over 256 are split into several sends. MACRO_REPEAT: number of runs of the block
+
+	Delay time.Duration // MACRO_DELAY: duration to wait
+	Steps []MacroStep   // MACRO_REPEAT: the block
+	Macro string        // MACRO_CALL: name of the macro
+	Power bool          // MACRO_POWER: true to turn on
+}
+
+// A named sequence of steps.
+type Macro struct {
+	Name  string
+	Steps []MacroStep
+}
+
+// Progress of a running macro.
+type MacroProgress struct {
+	Macro   string // name of the macro running the step
+	Step    int    // index of the step in the macro or the block
+	Total   int    // number of steps in the macro or the block
+	Depth   int    // nesting level of repeat blocks and called macros. Zero for the top level
+	Label   string // description of the step
+	Attempt int    // attempt number from 1
+	Err     error  // if not nil, the attempt failed with this error
+}
+
+// Device operations used by macros.
+type macroDevice interface {
+	SendRemoteControlCode(rtype RemoteType, code []byte, count int) error
+	SetPower(on bool) error
+}
+
+// Runner of macros. A runner is not safe for concurrent use.
+type MacroRunner struct {
+	Macros map[string]*Macro // macros to run by name, including those called by MACRO_CALL steps
+
+	Retries    int           // number of retries of a failed step with ON_ERROR_RETRY. Zero for 2
+	RetryDelay time.Duration // wait before a retry. Zero for 1 second
+
+	// Called before each attempt of a step, and after a failed attempt with Err set. May be nil.
+	Progress func(p MacroProgress)
+
+	devices map[string]macroDevice // devices by MAC address. "" for the default device
+}
+
+// Make a send step. count is the number of sends: 1 for once, 2 for twice, ...
+// A device sends a code up to 256 times at a call, so a larger count is sent with several calls. A retry of the step sends the whole count again.
+func SendStep(rtype RemoteType, code []byte, count int) MacroStep {
+	return MacroStep{Type: MACRO_SEND, RemoteType: rtype, Code: code, Count: count}
+}
+
+// Make a delay step.
+func DelayStep(d time.Duration) MacroStep {
+	return MacroStep{Type: MACRO_DELAY, Delay: d}
+}
+
+// Make a step to run a block of steps times times.
+func RepeatStep(times int, steps ...MacroStep) MacroStep {
+	return MacroStep{Type: MACRO_REPEAT, Count: times, Steps: steps}
+}
+
+// Make a step to run another macro of the runner.
+func CallStep(macro string) MacroStep {
+	return MacroStep{Type: MACRO_CALL, Macro: macro}
+}
+
+// Make a step to turn a power plug on or off. device is the MAC address of the plug.
+func PowerStep(device string, on bool) MacroStep {
+	return MacroStep{Type: MACRO_POWER, Device: device, Power: on}
+}
+
+// Make a send step of a button of the library. The step is sent count times with the device assigned to the remote.
+// The code is copied, so later changes to the library do not affect the step.
+func (l *Library) SendStep(remote, button string, count int) (st MacroStep, err error) {
+	rm, err := l.Remote(remote)
+	if err != nil {
+		return
+	}
+	b, err := l.Button(remote, button)
+	if err != nil {
+		return
+	}
+	st = SendStep(b.Type, append([]byte{}, b.Code...), count)
+	st.Device, st.Label = rm.Device, remote+" "+button
+	return
+}
+
+// Make a macro runner with a default device for steps without a device.
+func NewMacroRunner(d *Device) *MacroRunner {
+	r := &MacroRunner{Macros: map[string]*Macro{}}
+	if d != nil {
+		r.setDevice("", d)
+	}
+	return r
+}
+
+// Add a macro to the runner. A macro of the same name is replaced.
+func (r *MacroRunner) AddMacro(m *Macro) {
+	if r.Macros == nil {
+		r.Macros = map[string]*Macro{}
+	}
+	r.Macros[m.Name] = m
+}
+
+// Register a device for steps of its MAC address.
+func (r *MacroRunner) AddDevice(d *Device) {
+	r.setDevice(net.HardwareAddr(d.MACAddr).String(), d)
+}
+
+// register a device by MAC address
+func (r *MacroRunner) setDevice(mac string, d macroDevice) {
+	if r.devices == nil {
+		r.devices = map[string]macroDevice{}
+	}
+	r.devices[strings.ToLower(mac)] = d
+}
+
+// Run a macro by name. If ctx is done, the macro stops with ctx.Err().
+// Errors of steps with ON_ERROR_CONTINUE are only reported to Progress.
+func (r *MacroRunner) Run(ctx context.Context, name string) (err error) {
+	return r.call(ctx, name, 0, nil)
+}
+
+// run a macro at a nesting level, checking the call stack for recursion
+func (r *MacroRunner) call(ctx context.Context, name string, depth int, stack []string) (err error) {
+	for _, s := range stack {
+		if s == name {
+			err = fmt.Errorf("recursive call of macro %s", name)
+			return
+		}
+	}
+	m, ok := r.Macros[name]
+	if !ok {
+		err = fmt.Errorf("macro %s not found", name)
+		return
+	}
+	return r.runSteps(ctx, name, depth, m.Steps, append(stack, name))
+}
+
+// run steps of a macro or a block. depth is the nesting level, and stack is the names of the macros called so far
+func (r *MacroRunner) runSteps(ctx context.Context, macro string, depth int, steps []MacroStep, stack []string) (err error) {
+	retries := r.Retries
+	if retries <= 0 {
+		retries = defaultMacroRetries
+	}
+	for i := range steps {
+		st := &steps[i]
+		attempts := 1
+		if st.OnError == ON_ERROR_RETRY {
+			attempts += retries
+		}
+		p := MacroProgress{Macro: macro, Step: i, Total: len(steps), Depth: depth, Label: st.label()}
+		for p.Attempt = 1; p.Attempt <= attempts; p.Attempt++ {
+			if err = ctx.Err(); err != nil {
+				return
+			}
+			if p.Attempt > 1 {
+				if err = sleepContext(ctx, r.retryDelay()); err != nil {
+					return
+				}
+			}
+			p.Err = nil
+			r.report(p)
+			if p.Err = r.runStep(ctx, st, macro, depth, stack); p.Err == nil {
+				break
+			}
+			if ctx.Err() != nil {
+				err = ctx.Err()
+				return
+			}
+			r.report(p)
+		}
+		if p.Err != nil && st.OnError != ON_ERROR_CONTINUE {
+			// a failure of a nested step is already described by its own level
+			var se *macroStepError
+			if errors.As(p.Err, &se) {
+				err = p.Err
+			} else {
+				err = &macroStepError{fmt.Errorf("macro %s step %d (%s): %w", macro, i+1, p.Label, p.Err)}
+			}
+			return
+		}
+	}
+	return
+}
+
+// run a step of a macro
+func (r *MacroRunner) runStep(ctx context.Context, st *MacroStep, macro string, depth int, stack []string) (err error) {
+	switch st.Type {
+	case MACRO_SEND:
+		d, e := r.device(st.Device)
+		if e != nil {
+			return e
+		}
+		count := st.Count
+		if count <= 0 {
+			count = 1
+		}
+		for ; count > 0; count -= maxSendCount {
+			n := count
+			if n > maxSendCount {
+				n = maxSendCount
+			}
+			if err = d.SendRemoteControlCode(st.RemoteType, st.Code, n); err != nil {
+				return
+			}
+		}
+		return
+	case MACRO_DELAY:
+		return sleepContext(ctx, st.Delay)
+	case MACRO_REPEAT:
+		for n := 0; n < st.Count; n++ {
+			if err = r.runSteps(ctx, macro, depth+1, st.Steps, stack); err != nil {
+				return
+			}
+		}
+		return
+	case MACRO_CALL:
+		return r.call(ctx, st.Macro, depth+1, stack)
+	case MACRO_POWER:
+		d, e := r.device(st.Device)
+		if e != nil {
+			return e
+		}
+		return d.SetPower(st.Power)
+	}
+	return fmt.Errorf("unknown macro step type %d", st.Type)
+}
+
+// Error of a failed macro step, prefixed with the macro and the step where the failure happened.
+type macroStepError struct {
+	err error
+}
+
+func (e *macroStepError) Error() string {
+	return e.err.Error()
+}
+
+func (e *macroStepError) Unwrap() error {
+	return errors.Unwrap(e.err)
+}
+
+// get a device by MAC address
+func (r *MacroRunner) device(mac string) (d macroDevice, err error) {
+	d, ok := r.devices[strings.ToLower(mac)]
+	if !ok {
+		if mac == "" {
+			err = fmt.Errorf("no default device")
+		} else {
+			err = fmt.Errorf("device %s is not registered", mac)
+		}
+	}
+	return
+}
+
+// get the wait before a retry
+func (r *MacroRunner) retryDelay() time.Duration {
+	if r.RetryDelay > 0 {
+		return r.RetryDelay
+	}
+	return defaultMacroRetryDelay
+}
+
+// report progress
+func (r *MacroRunner) report(p MacroProgress) {
+	if r.Progress != nil {
+		r.Progress(p)
+	}
+}
+
+// describe a step
+func (st *MacroStep) label() string {
+	if st.Label != "" {
+		return st.Label
+	}
+	switch st.Type {
+	case MACRO_SEND:
+		t := remoteTypeName(st.RemoteType)
+		if st.Count > 1 {
+			return fmt.Sprintf("send %s code x%d", t, st.Count)
+		}
+		return fmt.Sprintf("send %s code", t)
+	case MACRO_DELAY:
+		return fmt.Sprintf("wait %v", st.Delay)
+	case MACRO_REPEAT:
+		return fmt.Sprintf("repeat %d steps x%d", len(st.Steps), st.Count)
+	case MACRO_CALL:
+		return "call " + st.Macro
+	case MACRO_POWER:
+		if st.Power {
+			return "power on"
+		}
+		return "power off"
+	}
+	return fmt.Sprintf("step type %d", st.Type)
+}
+
+// wait for a duration or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
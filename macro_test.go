@@ -0,0 +1,183 @@
+package broadlink
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// a device recording operations
+type fakeMacroDevice struct {
+	name  string
+	log   *[]string
+	fails int // number of operations to fail first
+}
+
+func (f *fakeMacroDevice) SendRemoteControlCode(rtype RemoteType, code []byte, count int) error {
+	if f.fails > 0 {
+		f.fails--
+		return fmt.Errorf("failed sending remote control code")
+	}
+	*f.log = append(*f.log, fmt.Sprintf("%s send %x x%d", f.name, code, count))
+	return nil
+}
+
+func (f *fakeMacroDevice) SetPower(on bool) error {
+	if f.fails > 0 {
+		f.fails--
+		return fmt.Errorf("failed setting power state")
+	}
+	*f.log = append(*f.log, fmt.Sprintf("%s power %v", f.name, on))
+	return nil
+}
+
+func TestMacroRunner(t *testing.T) {
+	ctx := context.Background()
+	var log []string
+	rm := &fakeMacroDevice{name: "rm", log: &log}
+	plug := &fakeMacroDevice{name: "plug", log: &log}
+
+	r := NewMacroRunner(nil)
+	r.setDevice("", rm)
+	r.setDevice("34:ea:34:00:00:01", plug)
+	r.RetryDelay = time.Millisecond
+
+	r.AddMacro(&Macro{Name: "receiver on", Steps: []MacroStep{
+		PowerStep("34:EA:34:00:00:01", true),
+		SendStep(REMOTE_IR, []byte{2}, 1),
+	}})
+	r.AddMacro(&Macro{Name: "conference", Steps: []MacroStep{
+		SendStep(REMOTE_IR, []byte{1}, 1), // projector on
+		DelayStep(time.Millisecond),
+		SendStep(REMOTE_IR, []byte{3}, 2), // input HDMI2
+		CallStep("receiver on"),
+		RepeatStep(3, SendStep(REMOTE_IR, []byte{4}, 1)), // volume up
+	}})
+
+	var progress []MacroProgress
+	r.Progress = func(p MacroProgress) { progress = append(progress, p) }
+	if err := r.Run(ctx, "conference"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"rm send 01 x1", "rm send 03 x2", "plug power true", "rm send 02 x1",
+		"rm send 04 x1", "rm send 04 x1", "rm send 04 x1",
+	}
+	if strings.Join(log, ",") != strings.Join(expected, ",") {
+		t.Errorf("unexpected operations %v", log)
+	}
+	if len(progress) != 5+2+3 || progress[4].Macro != "receiver on" || progress[4].Depth != 1 || progress[4].Label != "power on" {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+
+	// nested repeat blocks report the macro they belong to
+	progress = nil
+	r.AddMacro(&Macro{Name: "nested", Steps: []MacroStep{
+		RepeatStep(1, RepeatStep(1, SendStep(REMOTE_IR, []byte{5}, 1)), CallStep("receiver on")),
+	}})
+	if err := r.Run(ctx, "nested"); err != nil {
+		t.Fatal(err)
+	}
+	depths := []int{0, 1, 2, 1, 2, 2}
+	if len(progress) != len(depths) {
+		t.Fatalf("unexpected progress %+v", progress)
+	}
+	for i, p := range progress {
+		macro := "nested"
+		if i >= 4 {
+			macro = "receiver on"
+		}
+		if p.Macro != macro || p.Depth != depths[i] {
+			t.Errorf("unexpected progress %+v", p)
+		}
+	}
+
+	// error policies
+	log, progress = nil, nil
+	rm.fails = 2
+	r.AddMacro(&Macro{Name: "policies", Steps: []MacroStep{
+		{Type: MACRO_SEND, Code: []byte{1}, OnError: ON_ERROR_CONTINUE},
+		{Type: MACRO_SEND, Code: []byte{2}, OnError: ON_ERROR_RETRY},
+		{Type: MACRO_SEND, Code: []byte{3}},
+	}})
+	if err := r.Run(ctx, "policies"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(log, ",") != "rm send 02 x1,rm send 03 x1" {
+		t.Errorf("unexpected operations %v", log)
+	}
+	failed := 0
+	for _, p := range progress {
+		if p.Err != nil {
+			failed++
+		}
+	}
+	if failed != 2 || progress[len(progress)-2].Attempt != 2 {
+		t.Errorf("unexpected progress %+v", progress)
+	}
+
+	rm.fails = 1
+	r.AddMacro(&Macro{Name: "abort", Steps: []MacroStep{
+		SendStep(REMOTE_IR, []byte{1}, 1),
+		SendStep(REMOTE_IR, []byte{2}, 1),
+	}})
+	if err := r.Run(ctx, "abort"); err == nil || !strings.Contains(err.Error(), "step 1") {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// a failure in a nested block is prefixed once, by the macro and step where it happened
+	rm.fails = 1
+	r.AddMacro(&Macro{Name: "outer", Steps: []MacroStep{
+		RepeatStep(1, DelayStep(0), SendStep(REMOTE_IR, []byte{1}, 1)),
+	}})
+	err := r.Run(ctx, "outer")
+	if err == nil || err.Error() != "macro outer step 2 (send IR code): failed sending remote control code" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// counts over 256 are split
+	log = nil
+	r.AddMacro(&Macro{Name: "hold", Steps: []MacroStep{SendStep(REMOTE_IR, []byte{6}, 600)}})
+	if err := r.Run(ctx, "hold"); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(log, ",") != "rm send 06 x256,rm send 06 x256,rm send 06 x88" {
+		t.Errorf("unexpected operations %v", log)
+	}
+
+	// recursion and missing macros
+	r.AddMacro(&Macro{Name: "loop", Steps: []MacroStep{CallStep("loop")}})
+	if err := r.Run(ctx, "loop"); err == nil {
+		t.Error("recursion not detected")
+	}
+	if err := r.Run(ctx, "missing"); err == nil {
+		t.Error("missing macro run")
+	}
+
+	// cancellation
+	r.AddMacro(&Macro{Name: "long", Steps: []MacroStep{DelayStep(time.Hour)}})
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := r.Run(ctx, "long"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestLibrarySendStep(t *testing.T) {
+	l := NewLibrary()
+	l.AddRemote(Remote{Name: "tv", Device: "34:ea:34:00:00:02"})
+	l.SetButton("tv", Button{Name: "Power", Type: REMOTE_IR, Code: []byte{1, 2, 3}})
+	st, err := l.SendStep("tv", "Power", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Device != "34:ea:34:00:00:02" || st.Count != 2 || st.Label != "tv Power" {
+		t.Errorf("unexpected step %+v", st)
+	}
+	if _, err := l.SendStep("tv", "Mute", 1); err == nil {
+		t.Error("missing button found")
+	}
+}
@@ -0,0 +1,143 @@
+package broadlink
+
+import (
+	"fmt"
+)
+
+const (
+	// Default relative tolerance of MatchOptions
+	defaultMatchTolerance = 0.25
+)
+
+// Options of CompareCodes() and FindNearestCode().
+type MatchOptions struct {
+	Tolerance    float64 // relative tolerance of each duration. Zero for the default of 0.25
+	MaxFrameDiff int     // allowed difference of frame counts. Zero requires the same frame count. Negative for any
+	FrameGap     int     // minimum space between frames in microseconds. Zero for the default of 5ms
+}
+
+// Compare two BroadLink codes to decide whether they represent the same signal.
+// Frames are compared pairwise by durations. Gaps between frames are not compared since they vary with how long a button is held.
+// score is from 0 to 1, 1 for identical timings. Codes with every compared duration within the tolerance score 0.5 or more, before a small penalty for each extra frame.
+// same is true if every compared duration is within the tolerance and the frame counts are close enough.
+func CompareCodes(a, b []byte, opt MatchOptions) (score float64, same bool, err error) {
+	pa, err := DecodeCode(a)
+	if err != nil {
+		return
+	}
+	pb, err := DecodeCode(b)
+	if err != nil {
+		return
+	}
+	score, same = comparePulses(pa, pb, opt)
+	return
+}
+
+// Find the nearest code to a code in a list of codes.
+// index is the index of the nearest code, -1 if no code in codes is valid. See CompareCodes() for score and same.
+func FindNearestCode(code []byte, codes [][]byte, opt MatchOptions) (index int, score float64, same bool, err error) {
+	index = -1
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	for i, c := range codes {
+		p, e := DecodeCode(c)
+		if e != nil {
+			continue
+		}
+		s, ok := comparePulses(pulses, p, opt)
+		if index < 0 || s > score {
+			index, score, same = i, s, ok
+		}
+	}
+	if index < 0 {
+		err = fmt.Errorf("no valid code to compare")
+	}
+	return
+}
+
+// compare two signals. See CompareCodes().
+func comparePulses(a, b []int, opt MatchOptions) (score float64, same bool) {
+	tol := opt.Tolerance
+	if tol <= 0 {
+		tol = defaultMatchTolerance
+	}
+	gap := opt.FrameGap
+	if gap <= 0 {
+		gap = frameGap
+	}
+	fa, fb := SplitFrames(a, gap), SplitFrames(b, gap)
+	nf := len(fa)
+	if len(fb) < nf {
+		nf = len(fb)
+	}
+
+	same = true
+	frameDiff := len(fa) - len(fb)
+	if frameDiff < 0 {
+		frameDiff = -frameDiff
+	}
+	if opt.MaxFrameDiff >= 0 && frameDiff > opt.MaxFrameDiff {
+		same = false
+	}
+
+	// sum of relative errors normalized by the tolerance, and count of durations out of the tolerance.
+	// A missing duration counts as out of the tolerance.
+	sum, count, mismatch := 0.0, 0, 0
+	for f := 0; f < nf; f++ {
+		x, y := frameBody(fa[f]), frameBody(fb[f])
+		n := len(x)
+		if len(y) != n {
+			if len(y) < n {
+				n = len(y)
+			}
+			extra := len(x) + len(y) - 2*n
+			sum += float64(extra)
+			count += extra
+			mismatch += extra
+		}
+		for i := 0; i < n; i++ {
+			d, m := float64(x[i]-y[i]), float64(x[i])
+			if d < 0 {
+				d = -d
+			}
+			if y[i] > x[i] {
+				m = float64(y[i])
+			}
+			e := d / m / tol
+			if e > 1 {
+				e = 1
+				mismatch++
+			}
+			sum += e
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, false
+	}
+
+	// Matching signals score from 0.5 to 1 by closeness of timings. Signals with mismatching durations score below 0.5.
+	closeness := 1 - sum/float64(count)
+	if mismatch == 0 {
+		score = 0.5 + 0.5*closeness
+	} else {
+		same = false
+		score = 0.5 * closeness * (1 - float64(mismatch)/float64(count))
+	}
+	// each extra frame lowers the score a little
+	score *= 1 - 0.05*float64(frameDiff)
+	if score < 0 {
+		score = 0
+	}
+	return
+}
+
+// durations of a frame without its trailing gap
+func frameBody(f []int) []int {
+	if len(f)%2 == 0 && len(f) > 0 {
+		return f[:len(f)-1]
+	}
+	return f
+}
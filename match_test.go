@@ -0,0 +1,59 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestCompareCodes(t *testing.T) {
+
+	necCode := func(cmd uint32, repeats int, jitter int) []byte {
+		code, err := EncodeIRCommand(IRCommand{Protocol: "NEC2", Address: 4, Command: cmd}, repeats)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, _ := DecodeCode(code)
+		for i := range p {
+			if i%2 == 0 {
+				p[i] += jitter
+			} else {
+				p[i] -= jitter
+			}
+		}
+		return EncodeCode(p)
+	}
+
+	a := necCode(8, 0, 0)
+
+	score, same, err := CompareCodes(a, necCode(8, 0, 60), MatchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !same || score < 0.75 || score >= 1 {
+		t.Errorf("jittered code: score %.3f, same %v", score, same)
+	}
+
+	// extra repeat frames
+	_, same, _ = CompareCodes(a, necCode(8, 2, 0), MatchOptions{})
+	if same {
+		t.Error("different frame counts matched")
+	}
+	score, same, _ = CompareCodes(a, necCode(8, 2, 0), MatchOptions{MaxFrameDiff: -1})
+	if !same || score >= 1 {
+		t.Errorf("repeated code: score %.3f, same %v", score, same)
+	}
+
+	// a different button
+	score2, same, _ := CompareCodes(a, necCode(9, 0, 0), MatchOptions{})
+	if same || score2 >= score {
+		t.Errorf("different code: score %.3f, same %v", score2, same)
+	}
+
+	library := [][]byte{necCode(7, 0, 0), necCode(8, 1, 30), necCode(9, 0, 0)}
+	index, _, same, err := FindNearestCode(a, library, MatchOptions{MaxFrameDiff: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 1 || !same {
+		t.Errorf("nearest code %d, same %v", index, same)
+	}
+}
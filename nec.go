@@ -0,0 +1,132 @@
+package broadlink
+
+import (
+	"fmt"
+)
+
+// NEC protocol family.
+//
+//	"NEC"    8-bit address with its inverse, or 16-bit extended address when Address > 0xff. Repeats are ditto frames. (NEC1)
+//	"NECext" always 16-bit address. Repeats are ditto frames.
+//	"NEC2"   same as NEC, but repeats are copies of the full frame.
+//
+// The command is 8 bits, sent with its inverse.
+
+var necTiming = pulseDistance{
+	headerMark: 9000, headerSpace: 4500,
+	bitMark:   560,
+	zeroSpace: 560, oneSpace: 1690,
+}
+
+const (
+	necPeriod       = 108000 // frame period in microseconds
+	necRepeatSpace  = 2250   // space of a ditto frame header
+	necMinGap       = 10000  // minimum gap between frames
+	necNameStandard = "NEC"
+	necNameExtended = "NECext"
+	necName2        = "NEC2"
+)
+
+func init() {
+	registerIRProtocol(&irProtocol{name: necNameStandard, frequency: 38000, encode: encodeNEC, decode: decodeNEC})
+	registerIRProtocol(&irProtocol{name: necNameExtended, frequency: 38000, encode: encodeNEC})
+	registerIRProtocol(&irProtocol{name: necName2, frequency: 38000, encode: encodeNEC})
+}
+
+// build 32-bit data of a NEC frame
+func necData(c IRCommand) (data uint64, err error) {
+	if c.Command > 0xff {
+		err = fmt.Errorf("NEC command must be 8 bits")
+		return
+	}
+	if c.Address > 0xffff {
+		err = fmt.Errorf("NEC address must be 8 or 16 bits")
+		return
+	}
+	addr := uint64(c.Address)
+	if c.Protocol != necNameExtended && c.Address <= 0xff {
+		addr |= (^addr & 0xff) << 8
+	}
+	cmd := uint64(c.Command)
+	data = addr | cmd<<16 | (^cmd&0xff)<<24
+	return
+}
+
+func encodeNEC(c IRCommand, repeats int) (pulses []int, err error) {
+	data, err := necData(c)
+	if err != nil {
+		return
+	}
+	pulses = necPulses(data, repeats, c.Protocol == necName2)
+	return
+}
+
+// render NEC frames of 32-bit data. Repeats are ditto frames, or copies of the full frame if full is set
+func necPulses(data uint64, repeats int, full bool) []int {
+	var b pulseBuilder
+	frame := func() {
+		start := b.duration()
+		necTiming.header(&b)
+		necTiming.bits(&b, data, 32)
+		b.mark(necTiming.bitMark)
+		padFrame(&b, start, necPeriod, necMinGap)
+	}
+	frame()
+	for i := 0; i < repeats; i++ {
+		if full {
+			frame()
+			continue
+		}
+		// ditto frame
+		start := b.duration()
+		b.mark(necTiming.headerMark)
+		b.space(necRepeatSpace)
+		b.mark(necTiming.bitMark)
+		padFrame(&b, start, necPeriod, necMinGap)
+	}
+	return b.pulses
+}
+
+// read a full NEC frame at pulses[i]
+func readNECFrame(pulses []int, i int) (data uint64, next int, ok bool) {
+	if !necTiming.matchHeader(pulses, i) {
+		return
+	}
+	data, next, ok = necTiming.readBits(pulses, i+2, 32)
+	if !ok || next >= len(pulses) || !matchDuration(pulses[next], necTiming.bitMark) || !matchGap(pulses, next+1, necMinGap) {
+		ok = false
+		return
+	}
+	next += 2
+	return
+}
+
+func decodeNEC(pulses []int) (c IRCommand, ok bool) {
+	data, next, ok := readNECFrame(pulses, 0)
+	if !ok {
+		return
+	}
+	cmd, icmd := data>>16&0xff, data>>24&0xff
+	if cmd^icmd != 0xff {
+		ok = false
+		return
+	}
+	addr, iaddr := data&0xff, data>>8&0xff
+
+	c.Command = uint32(cmd)
+	if addr^iaddr == 0xff {
+		c.Protocol = necNameStandard
+		c.Address = uint32(addr)
+	} else {
+		c.Protocol = necNameExtended
+		c.Address = uint32(data & 0xffff)
+	}
+
+	// a full frame repeat makes it NEC2
+	if c.Protocol == necNameStandard {
+		if d2, _, ok2 := readNECFrame(pulses, next); ok2 && d2 == data {
+			c.Protocol = necName2
+		}
+	}
+	return
+}
@@ -0,0 +1,58 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestNEC(t *testing.T) {
+
+	tests := []struct {
+		in      IRCommand
+		pulses  int
+		decoded IRCommand
+	}{
+		// header, 32 bits, stop bit and gap + two ditto frames
+		{IRCommand{Protocol: "NEC", Address: 0x04, Command: 0x08}, 68 + 4 + 4, IRCommand{Protocol: "NEC", Address: 0x04, Command: 0x08}},
+		{IRCommand{Protocol: "NEC", Address: 0x1234, Command: 0x56}, 68 + 4 + 4, IRCommand{Protocol: "NECext", Address: 0x1234, Command: 0x56}},
+		{IRCommand{Protocol: "NECext", Address: 0x04, Command: 0x08}, 68 + 4 + 4, IRCommand{Protocol: "NECext", Address: 0x0004, Command: 0x08}},
+		{IRCommand{Protocol: "NEC2", Address: 0x04, Command: 0x08}, 68 * 3, IRCommand{Protocol: "NEC2", Address: 0x04, Command: 0x08}},
+	}
+
+	for _, tc := range tests {
+		code, err := EncodeIRCommand(tc.in, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulses, err := DecodeCode(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pulses) != tc.pulses {
+			t.Errorf("%v: unexpected pulse count %d", tc.in, len(pulses))
+		}
+
+		// add some jitter like a captured code
+		for i := range pulses {
+			if i%2 == 0 {
+				pulses[i] += 80
+			} else {
+				pulses[i] -= 80
+			}
+		}
+		c, err := DecodeIRCommand(EncodeCode(pulses))
+		if err != nil {
+			t.Errorf("%v: %v", tc.in, err)
+			continue
+		}
+		if c != tc.decoded {
+			t.Errorf("%v: decoded as %v", tc.in, c)
+		}
+	}
+
+	if _, err := EncodeIRCommand(IRCommand{Protocol: "NEC", Address: 0x04, Command: 0x100}, 0); err == nil {
+		t.Error("9-bit command accepted")
+	}
+	if _, err := EncodeIRCommand(IRCommand{Protocol: "NOSUCH"}, 0); err != ErrUnknownProtocol {
+		t.Error("unknown protocol accepted")
+	}
+}
@@ -0,0 +1,111 @@
+package broadlink
+
+import (
+	"sort"
+)
+
+const (
+	// Default relative tolerance of QuantizePulses()
+	defaultQuantizeTolerance = 0.2
+)
+
+// Options of NormalizeCode().
+type NormalizeOptions struct {
+	Tolerance float64 // relative tolerance to cluster durations. Zero for the default of 0.2. Negative to disable quantization
+	FrameGap  int     // minimum space between frames in microseconds. Zero for the default of 5ms
+	MaxFrames int     // number of frames to keep. Zero to keep all
+	MaxGap    int     // maximum trailing gap in microseconds. Zero to keep the gap. Negative to end the code with the standard terminator
+}
+
+// Split pulses into frames at spaces of minGap microseconds or longer. Each frame keeps its trailing gap.
+func SplitFrames(pulses []int, minGap int) (frames [][]int) {
+	start := 0
+	for i := 1; i < len(pulses); i += 2 {
+		if pulses[i] >= minGap {
+			frames = append(frames, pulses[start:i+1])
+			start = i + 1
+		}
+	}
+	if start < len(pulses) {
+		frames = append(frames, pulses[start:])
+	}
+	return
+}
+
+// Snap jittery durations to representative values.
+// Marks and spaces are clustered separately. Sorted durations within tolerance of the smallest one of a cluster form the cluster,
+// and each duration is replaced by the mean of its cluster.
+func QuantizePulses(pulses []int, tolerance float64) (quantized []int) {
+	if tolerance <= 0 {
+		tolerance = defaultQuantizeTolerance
+	}
+	quantized = make([]int, len(pulses))
+	for parity := 0; parity < 2; parity++ {
+		idx := []int{}
+		for i := parity; i < len(pulses); i += 2 {
+			idx = append(idx, i)
+		}
+		sort.Slice(idx, func(a, b int) bool { return pulses[idx[a]] < pulses[idx[b]] })
+
+		for start := 0; start < len(idx); {
+			end, sum := start, 0
+			limit := float64(pulses[idx[start]]) * (1 + tolerance)
+			for end < len(idx) && float64(pulses[idx[end]]) <= limit {
+				sum += pulses[idx[end]]
+				end++
+			}
+			mean := (sum + (end-start)/2) / (end - start)
+			for _, i := range idx[start:end] {
+				quantized[i] = mean
+			}
+			start = end
+		}
+	}
+	return
+}
+
+// Limit the trailing gap of pulses to maxGap microseconds. If maxGap is negative, the trailing gap is removed.
+func TrimGap(pulses []int, maxGap int) (trimmed []int) {
+	trimmed = append([]int{}, pulses...)
+	n := len(trimmed)
+	if n == 0 || n%2 == 1 {
+		return
+	}
+	if maxGap < 0 {
+		trimmed = trimmed[:n-1]
+	} else if trimmed[n-1] > maxGap {
+		trimmed[n-1] = maxGap
+	}
+	return
+}
+
+// Clean up a captured code for storage and replay: quantize jittery durations, keep the first frames and trim the trailing gap.
+// The result can be passed to SendRemoteControlCode().
+func NormalizeCode(code []byte, opt NormalizeOptions) (normalized []byte, err error) {
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	if opt.Tolerance >= 0 {
+		pulses = QuantizePulses(pulses, opt.Tolerance)
+	}
+	if opt.MaxFrames > 0 {
+		gap := opt.FrameGap
+		if gap <= 0 {
+			gap = frameGap
+		}
+		frames := SplitFrames(pulses, gap)
+		if len(frames) > opt.MaxFrames {
+			kept := []int{}
+			for _, f := range frames[:opt.MaxFrames] {
+				kept = append(kept, f...)
+			}
+			pulses = kept
+		}
+	}
+	if opt.MaxGap != 0 {
+		pulses = TrimGap(pulses, opt.MaxGap)
+	}
+	normalized = EncodeCode(pulses)
+	return
+}
@@ -0,0 +1,48 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+
+	code, err := EncodeIRCommand(IRCommand{Protocol: "NEC2", Address: 4, Command: 8}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulses, _ := DecodeCode(code)
+	for i := range pulses {
+		pulses[i] += []int{-40, 30, 40, -30}[i%4]
+	}
+	jittered := EncodeCode(pulses)
+
+	normalized, err := NormalizeCode(jittered, NormalizeOptions{MaxFrames: 1, MaxGap: 20000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := DecodeCode(normalized)
+	if len(p) != 68 {
+		t.Fatalf("unexpected pulse count %d", len(p))
+	}
+	if p[67] != 20000 {
+		t.Errorf("trailing gap not capped: %d", p[67])
+	}
+	for i := 2; i < 66; i += 2 {
+		if p[i] != p[2] {
+			t.Fatalf("bit marks not quantized: %v", p)
+		}
+	}
+	c, err := DecodeIRCommand(normalized)
+	if err != nil || c.Address != 4 || c.Command != 8 {
+		t.Errorf("normalized code decoded as %v, %v", c, err)
+	}
+
+	q := QuantizePulses([]int{500, 560, 1500, 600, 1650, 580}, 0)
+	if q[0] != 500 || q[2] != 1575 || q[4] != 1575 || q[1] != 580 || q[3] != 580 || q[5] != 580 {
+		t.Errorf("invalid quantization %v", q)
+	}
+
+	if g := TrimGap([]int{500, 500, 500, 90000}, -1); len(g) != 3 {
+		t.Errorf("trailing gap not removed: %v", g)
+	}
+}
@@ -0,0 +1,51 @@
+package broadlink
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Turn the relay of a power plug of SP2 class (SP2, SP3, SPMini, ...) on or off.
+func (d *Device) SetPower(on bool) (err error) {
+	packet := make([]byte, 0x10)
+	packet[0] = 0x02 // sub-command 0x02: set power state
+	if on {
+		packet[4] = 1
+	}
+
+	res, err := d.Call(0x6a, packet)
+	if err != nil {
+		return
+	}
+	rescode := binary.LittleEndian.Uint16(res[0x22:0x24])
+	if rescode != 0 {
+		err = fmt.Errorf("failed setting power state (%04x)", rescode)
+	}
+	return
+}
+
+// Read the relay state of a power plug of SP2 class.
+func (d *Device) Power() (on bool, err error) {
+	packet := make([]byte, 0x10)
+	packet[0] = 0x01 // sub-command 0x01: read power state
+
+	res, err := d.Call(0x6a, packet)
+	if err != nil {
+		return
+	}
+	rescode := binary.LittleEndian.Uint16(res[0x22:0x24])
+	if rescode != 0 {
+		err = fmt.Errorf("failed reading power state (%04x)", rescode)
+		return
+	}
+	data, err := d.getPayload(res)
+	if err != nil {
+		return
+	}
+	if len(data) < 5 {
+		err = fmt.Errorf("incomplete data")
+		return
+	}
+	on = data[4]&0x01 != 0 // bit 1 is the night light of SP3
+	return
+}
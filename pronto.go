@@ -0,0 +1,150 @@
+package broadlink
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Pronto frequency word unit in microseconds. Carrier period = word * prontoClock.
+	prontoClock = 0.241246
+
+	// Carrier frequency assumed when none is given.
+	defaultCarrierFrequency = 38000
+
+	// Trailing gap appended to sequences ending with a mark, in microseconds.
+	defaultTrailingGap = 100000
+)
+
+// Decode a Pronto Hex code of learned (0000) format.
+// freq is the carrier frequency in Hz. once and repeat are the once and repeat sequences in alternating mark and space durations of microseconds.
+func DecodePronto(pronto string) (freq int, once, repeat []int, err error) {
+	fields := strings.Fields(pronto)
+	if len(fields) < 4 {
+		err = fmt.Errorf("pronto code too short")
+		return
+	}
+	words := make([]int, len(fields))
+	for i, f := range fields {
+		v, e := strconv.ParseUint(f, 16, 16)
+		if e != nil {
+			err = fmt.Errorf("invalid pronto word %q", f)
+			return
+		}
+		words[i] = int(v)
+	}
+
+	if words[0] != 0x0000 {
+		err = fmt.Errorf("unsupported pronto format %04x", words[0])
+		return
+	}
+	if words[1] == 0 {
+		err = fmt.Errorf("invalid pronto frequency word")
+		return
+	}
+	period := float64(words[1]) * prontoClock // carrier period in microseconds
+	freq = int(1000000/period + 0.5)
+
+	nOnce, nRepeat := words[2]*2, words[3]*2
+	if len(words) < 4+nOnce+nRepeat {
+		err = fmt.Errorf("incomplete pronto code")
+		return
+	}
+	conv := func(w []int) []int {
+		if len(w) == 0 {
+			return nil
+		}
+		d := make([]int, len(w))
+		for i, v := range w {
+			d[i] = int(float64(v)*period + 0.5)
+		}
+		return d
+	}
+	once = conv(words[4 : 4+nOnce])
+	repeat = conv(words[4+nOnce : 4+nOnce+nRepeat])
+	return
+}
+
+// Encode once and repeat sequences into a Pronto Hex code of learned (0000) format.
+// freq is the carrier frequency in Hz. If freq is zero, 38kHz is assumed.
+// Sequences ending with a mark get a trailing gap.
+func EncodePronto(freq int, once, repeat []int) (pronto string, err error) {
+	if freq == 0 {
+		freq = defaultCarrierFrequency
+	}
+	if freq < 0 {
+		err = fmt.Errorf("invalid carrier frequency %d", freq)
+		return
+	}
+	fw := int(1000000/(float64(freq)*prontoClock) + 0.5)
+	if fw < 1 || fw > 0xffff {
+		err = fmt.Errorf("carrier frequency %d out of range", freq)
+		return
+	}
+	period := float64(fw) * prontoClock
+
+	once, repeat = evenPulses(once), evenPulses(repeat)
+
+	words := []int{0x0000, fw, len(once) / 2, len(repeat) / 2}
+	for _, seq := range [][]int{once, repeat} {
+		for _, d := range seq {
+			w := int(float64(d)/period + 0.5)
+			if w < 1 {
+				w = 1
+			} else if w > 0xffff {
+				w = 0xffff
+			}
+			words = append(words, w)
+		}
+	}
+
+	s := make([]string, len(words))
+	for i, w := range words {
+		s[i] = fmt.Sprintf("%04X", w)
+	}
+	pronto = strings.Join(s, " ")
+	return
+}
+
+// Convert a Pronto Hex code into a code for SendIRRemoteCode().
+// The once sequence is followed by repeats times of the repeat sequence. If the code has no once sequence, the repeat sequence is included at least once.
+// The carrier is dropped without a check. Use ProntoToSignal() to keep it for Signal.CheckCarrier().
+func ProntoToCode(pronto string, repeats int) (code []byte, err error) {
+	_, once, repeat, err := DecodePronto(pronto)
+	if err != nil {
+		return
+	}
+	if len(once) == 0 && repeats < 1 {
+		repeats = 1
+	}
+	pulses := append([]int{}, once...)
+	for i := 0; i < repeats; i++ {
+		pulses = append(pulses, repeat...)
+	}
+	if len(pulses) == 0 {
+		err = fmt.Errorf("empty pronto code")
+		return
+	}
+	code = EncodeCode(pulses)
+	return
+}
+
+// Convert a BroadLink IR code into a Pronto Hex code. The whole code is stored as the once sequence.
+// freq is the carrier frequency in Hz. If freq is zero, 38kHz is assumed.
+func CodeToPronto(code []byte, freq int) (pronto string, err error) {
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	return EncodePronto(freq, pulses, nil)
+}
+
+// Make a copy of pulses that ends with a space.
+func evenPulses(pulses []int) []int {
+	p := append([]int{}, pulses...)
+	if len(p)%2 == 1 {
+		p = append(p, defaultTrailingGap)
+	}
+	return p
+}
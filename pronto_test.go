@@ -0,0 +1,66 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestPronto(t *testing.T) {
+
+	// NEC header, one bit, trailing mark in once sequence and NEC repeat frame in repeat sequence
+	src := "0000 006D 0003 0002 0156 00AB 0016 0041 0016 05F7 0156 0055 0016 0E47"
+
+	freq, once, repeat, err := DecodePronto(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if freq != 38029 {
+		t.Errorf("invalid frequency %d", freq)
+	}
+	if len(once) != 6 || len(repeat) != 4 {
+		t.Fatalf("invalid sequence size %d, %d", len(once), len(repeat))
+	}
+	if once[0] != 8993 || once[1] != 4497 {
+		t.Errorf("invalid header timings %d, %d", once[0], once[1])
+	}
+
+	encoded, err := EncodePronto(freq, once, repeat)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encoded != src {
+		t.Fatalf("round trip failed:\n%s\n%s", src, encoded)
+	}
+
+	// once sequence followed by two repeat frames
+	code, err := ProntoToCode(src, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulses) != 14 {
+		t.Fatalf("unexpected pulse count %d", len(pulses))
+	}
+
+	// the whole code goes to the once sequence
+	p, err := CodeToPronto(code, freq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, once, repeat, err = DecodePronto(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(once) != 14 || len(repeat) != 0 {
+		t.Fatalf("invalid sequence size %d, %d", len(once), len(repeat))
+	}
+
+	if _, _, _, err = DecodePronto("0100 006D 0000 0001 0001 0001"); err == nil {
+		t.Error("unsupported format accepted")
+	}
+	if _, _, _, err = DecodePronto("0000 006D 0000 0002 0001 0001"); err == nil {
+		t.Error("incomplete code accepted")
+	}
+}
@@ -0,0 +1,194 @@
+package broadlink
+
+import (
+	"fmt"
+)
+
+// Philips bi-phase protocols.
+//
+//	"RC5" 5-bit address, 7-bit command. Commands over 63 use the RC5X field bit.
+//	"RC6" RC6 mode 0. 8-bit address, 8-bit command.
+//
+// Both protocols have a toggle bit which flips on each key press.
+
+const (
+	rc5Unit   = 889    // half bit duration in microseconds
+	rc5Period = 113778 // frame period in microseconds
+
+	rc6Unit   = 444
+	rc6Period = 106700
+)
+
+func init() {
+	registerIRProtocol(&irProtocol{name: "RC5", frequency: 36000, toggle: true, encode: encodeRC5, decode: decodeRC5})
+	registerIRProtocol(&irProtocol{name: "RC6", frequency: 36000, toggle: true, encode: encodeRC6, decode: decodeRC6})
+}
+
+// Append a bi-phase bit. With markFirst, a one bit is a mark followed by a space; otherwise a space followed by a mark.
+func biphaseBit(b *pulseBuilder, one, markFirst bool, unit int) {
+	if one == markFirst {
+		b.mark(unit)
+		b.space(unit)
+	} else {
+		b.space(unit)
+		b.mark(unit)
+	}
+}
+
+// Expand a frame of pulses into half-bit levels of unit duration, true for a mark.
+// The frame ends at a space longer than maxUnits. next is the index after the frame.
+func biphaseLevels(pulses []int, unit, maxUnits int) (levels []bool, next int, ok bool) {
+	for next = 0; next < len(pulses); next++ {
+		n := (pulses[next] + unit/2) / unit
+		mark := next%2 == 0
+		if !mark && n > maxUnits {
+			next++
+			break
+		}
+		if n < 1 || n > maxUnits || !matchDuration(pulses[next], n*unit) {
+			return
+		}
+		for i := 0; i < n; i++ {
+			levels = append(levels, mark)
+		}
+	}
+	ok = true
+	return
+}
+
+// Read bi-phase bits from half-bit levels. See biphaseBit() for markFirst.
+func biphaseRead(levels []bool, i, nbits int, markFirst bool) (data uint64, next int, ok bool) {
+	for n := 0; n < nbits; n++ {
+		if i+1 >= len(levels) {
+			return
+		}
+		a, b := levels[i], levels[i+1]
+		if a == b {
+			return
+		}
+		bit := uint64(0)
+		if a == markFirst {
+			bit = 1
+		}
+		data = data<<1 | bit
+		i += 2
+	}
+	next, ok = i, true
+	return
+}
+
+func encodeRC5(c IRCommand, repeats int) (pulses []int, err error) {
+	if c.Address > 0x1f || c.Command > 0x7f {
+		err = fmt.Errorf("RC5 address must be 5 bits and command 7 bits")
+		return
+	}
+	// start bit, field bit (inverted command bit 6), toggle, address and command
+	data := uint64(1)<<13 | uint64(^c.Command>>6&1)<<12 | uint64(c.Address)<<6 | uint64(c.Command&0x3f)
+	if c.Toggle {
+		data |= 1 << 11
+	}
+	var b pulseBuilder
+	for f := 0; f <= repeats; f++ {
+		start := b.duration()
+		for i := 13; i >= 0; i-- {
+			biphaseBit(&b, data>>uint(i)&1 == 1, false, rc5Unit)
+		}
+		padFrame(&b, start, rc5Period, rc5Unit*4)
+	}
+	pulses = b.pulses
+	return
+}
+
+func decodeRC5(pulses []int) (c IRCommand, ok bool) {
+	levels, _, ok := biphaseLevels(pulses, rc5Unit, 2)
+	if !ok {
+		return
+	}
+	// the leading half of the start bit is a space, which is not captured
+	levels = append([]bool{false}, levels...)
+	if len(levels)%2 == 1 {
+		levels = append(levels, false)
+	}
+	if len(levels) != 28 {
+		ok = false
+		return
+	}
+	data, _, ok := biphaseRead(levels, 0, 14, false)
+	if !ok || data>>13 != 1 {
+		ok = false
+		return
+	}
+	c.Protocol = "RC5"
+	c.Toggle = data>>11&1 == 1
+	c.Address = uint32(data >> 6 & 0x1f)
+	c.Command = uint32(data&0x3f) | uint32(^data>>12&1)<<6
+	return
+}
+
+func encodeRC6(c IRCommand, repeats int) (pulses []int, err error) {
+	if c.Address > 0xff || c.Command > 0xff {
+		err = fmt.Errorf("RC6 address and command must be 8 bits")
+		return
+	}
+	var b pulseBuilder
+	for f := 0; f <= repeats; f++ {
+		start := b.duration()
+		b.mark(rc6Unit * 6) // leader
+		b.space(rc6Unit * 2)
+		biphaseBit(&b, true, true, rc6Unit) // start bit
+		for i := 0; i < 3; i++ {            // mode 0
+			biphaseBit(&b, false, true, rc6Unit)
+		}
+		biphaseBit(&b, c.Toggle, true, rc6Unit*2) // double length trailer bit
+		for i := 7; i >= 0; i-- {
+			biphaseBit(&b, c.Address>>uint(i)&1 == 1, true, rc6Unit)
+		}
+		for i := 7; i >= 0; i-- {
+			biphaseBit(&b, c.Command>>uint(i)&1 == 1, true, rc6Unit)
+		}
+		padFrame(&b, start, rc6Period, rc6Unit*6)
+	}
+	pulses = b.pulses
+	return
+}
+
+func decodeRC6(pulses []int) (c IRCommand, ok bool) {
+	levels, _, ok := biphaseLevels(pulses, rc6Unit, 6)
+	if !ok {
+		return
+	}
+	if len(levels)%2 == 1 {
+		levels = append(levels, false)
+	}
+	// leader 6 marks and 2 spaces, start bit, 3 mode bits, trailer of 4 units and 16 bits
+	if len(levels) != 8+2+6+4+32 {
+		ok = false
+		return
+	}
+	for i := 0; i < 8; i++ {
+		if levels[i] != (i < 6) {
+			ok = false
+			return
+		}
+	}
+	header, next, ok := biphaseRead(levels, 8, 4, true)
+	if !ok || header != 0x8 { // start bit 1, mode 0
+		ok = false
+		return
+	}
+	// trailer bit of double length
+	t := levels[next : next+4]
+	if t[0] != t[1] || t[2] != t[3] || t[0] == t[2] {
+		ok = false
+		return
+	}
+	data, _, ok := biphaseRead(levels, next+4, 16, true)
+	if !ok {
+		return
+	}
+	c.Protocol = "RC6"
+	c.Toggle = t[0]
+	c.Address = uint32(data >> 8)
+	c.Command = uint32(data & 0xff)
+	return
+}
@@ -0,0 +1,66 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestBiphaseAndSIRC(t *testing.T) {
+
+	tests := []struct {
+		c      IRCommand
+		pulses []int // expected leading pulses
+	}{
+		// RC5: start bit 1, field bit 1, toggle 0, address 00101, command 000011
+		{IRCommand{Protocol: "RC5", Address: 0x05, Command: 0x03}, []int{889, 889, 1778, 889, 889, 889}},
+		{IRCommand{Protocol: "RC5", Address: 0x05, Command: 0x43, Toggle: true}, nil},
+		{IRCommand{Protocol: "RC6", Address: 0x00, Command: 0x0c}, []int{2664, 888, 444, 888, 444, 444}},
+		{IRCommand{Protocol: "RC6", Address: 0x80, Command: 0x01, Toggle: true}, nil},
+		{IRCommand{Protocol: "SIRC", Address: 0x01, Command: 0x15}, []int{2400, 600, 1200, 600, 600, 600}},
+		{IRCommand{Protocol: "SIRC15", Address: 0x9a, Command: 0x7f}, nil},
+		{IRCommand{Protocol: "SIRC20", Address: 0x1abc, Command: 0x01}, nil},
+	}
+
+	for _, tc := range tests {
+		code, err := EncodeIRCommand(tc.c, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulses, err := DecodeCode(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, p := range tc.pulses {
+			if !within(pulses[i], p) {
+				t.Errorf("%v: pulses %v, expected %v", tc.c, pulses[:len(tc.pulses)], tc.pulses)
+				break
+			}
+		}
+		c, err := DecodeIRCommand(code)
+		if err != nil {
+			t.Errorf("%v: %v", tc.c, err)
+			continue
+		}
+		if c != tc.c {
+			t.Errorf("%v: decoded as %v", tc.c, c)
+		}
+	}
+
+	// toggle flips on each command
+	var d Device
+	if d.nextIRToggle("RC5") || !d.nextIRToggle("RC5") || d.nextIRToggle("RC5") || d.nextIRToggle("RC6") {
+		t.Error("toggle not flipped")
+	}
+
+	// toggle is kept when sending fails
+	d = Device{}
+	if err := d.SendIRCommand(IRCommand{Protocol: "RC5", Address: 1, Command: 2}, 0); err == nil {
+		t.Fatal("sent without a MAC address")
+	}
+	if d.nextIRToggle("RC5") {
+		t.Error("toggle flipped by a failed send")
+	}
+
+	if _, err := EncodeIRCommand(IRCommand{Protocol: "SIRC", Address: 0x20}, 0); err == nil {
+		t.Error("6-bit SIRC address accepted")
+	}
+}
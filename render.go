@@ -0,0 +1,197 @@
+package broadlink
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	defaultSVGScale       = 10  // microseconds per pixel
+	defaultASCIIScale     = 100 // microseconds per character
+	defaultWAVSampleRate  = 48000
+	svgRowHeight          = 60
+	svgMarkHeight         = 30
+	svgMargin             = 10
+	svgMinLabelWidth      = 30 // minimum pulse width in pixels to have a timing label
+	asciiMaxGapCharacters = 10
+)
+
+// Write a code as an SVG waveform. Each frame is drawn in its own row, with durations in microseconds labeled over wide enough pulses and the gap at the end of the row.
+// scale is microseconds per pixel. If scale is zero, 10 is used.
+func WriteSVGWaveform(w io.Writer, code []byte, scale float64) (err error) {
+	if scale == 0 {
+		scale = defaultSVGScale
+	}
+	if scale < 0 {
+		err = fmt.Errorf("invalid scale %g", scale)
+		return
+	}
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	frames := SplitFrames(pulses, frameGap)
+
+	// width of the longest frame without its gap
+	width := 0.0
+	for _, f := range frames {
+		d := 0
+		for _, v := range frameBody(f) {
+			d += v
+		}
+		width = math.Max(width, float64(d)/scale)
+	}
+	gapLabel := 120.0 // room for the gap label
+	totalWidth := width + gapLabel + svgMargin*2
+	totalHeight := float64(len(frames)*svgRowHeight + svgMargin*2)
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%.0f\" height=\"%.0f\" font-family=\"monospace\" font-size=\"10\">\n", totalWidth, totalHeight)
+	fmt.Fprintf(bw, "<rect width=\"100%%\" height=\"100%%\" fill=\"white\"/>\n")
+	for n, f := range frames {
+		top := float64(svgMargin + n*svgRowHeight + 15)
+		high, low := top, top+svgMarkHeight
+		x := float64(svgMargin)
+
+		// waveform
+		var path strings.Builder
+		fmt.Fprintf(&path, "M%.1f %.1f", x, low)
+		body := frameBody(f)
+		for i, d := range body {
+			dx := float64(d) / scale
+			if i%2 == 0 {
+				fmt.Fprintf(&path, " V%.1f H%.1f V%.1f", high, x+dx, low)
+			} else {
+				fmt.Fprintf(&path, " H%.1f", x+dx)
+			}
+			if dx >= svgMinLabelWidth {
+				fmt.Fprintf(bw, "<text x=\"%.1f\" y=\"%.1f\" text-anchor=\"middle\">%d</text>\n", x+dx/2, top-3, d)
+			}
+			x += dx
+		}
+		fmt.Fprintf(bw, "<path d=\"%s\" fill=\"none\" stroke=\"black\"/>\n", path.String())
+
+		// frame boundary and the gap
+		fmt.Fprintf(bw, "<line x1=\"%.1f\" y1=\"%.1f\" x2=\"%.1f\" y2=\"%.1f\" stroke=\"red\" stroke-dasharray=\"2,2\"/>\n", x, top-10, x, low+5)
+		if len(body) < len(f) {
+			fmt.Fprintf(bw, "<text x=\"%.1f\" y=\"%.1f\" fill=\"red\">frame %d, gap %d</text>\n", x+5, low, n+1, f[len(f)-1])
+		} else {
+			fmt.Fprintf(bw, "<text x=\"%.1f\" y=\"%.1f\" fill=\"red\">frame %d</text>\n", x+5, low, n+1)
+		}
+	}
+	fmt.Fprintf(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// Format a code as an ASCII timeline of '#' for marks and '_' for spaces, one frame per line.
+// scale is microseconds per character. If scale is zero, 100 is used. Every pulse takes at least one character, and gaps between frames are shortened and labeled.
+func FormatASCIIWaveform(code []byte, scale int) (s string, err error) {
+	if scale == 0 {
+		scale = defaultASCIIScale
+	}
+	if scale < 0 {
+		err = fmt.Errorf("invalid scale %d", scale)
+		return
+	}
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	var sb strings.Builder
+	for _, f := range SplitFrames(pulses, frameGap) {
+		body := frameBody(f)
+		for i, d := range body {
+			n := (d + scale/2) / scale
+			if n < 1 {
+				n = 1
+			}
+			c := "#"
+			if i%2 != 0 {
+				c = "_"
+			}
+			sb.WriteString(strings.Repeat(c, n))
+		}
+		if len(body) < len(f) {
+			gap := f[len(f)-1]
+			n := gap / scale
+			if n > asciiMaxGapCharacters {
+				n = asciiMaxGapCharacters
+			}
+			fmt.Fprintf(&sb, "%s %d", strings.Repeat("_", n), gap)
+		}
+		sb.WriteByte('\n')
+	}
+	s = sb.String()
+	return
+}
+
+// Write a code as a 16-bit stereo WAV file for audio jack IR blasters.
+// Marks are a sine wave of half the carrier frequency, with the right channel inverted, so that two IR LEDs connected in anti-parallel across the channels blink at the carrier frequency.
+// freq is the carrier frequency in Hz; if zero, 38kHz is assumed. sampleRate is in Hz; if zero, 48kHz is used. freq must be lower than sampleRate.
+func WriteWAV(w io.Writer, code []byte, freq, sampleRate int) (err error) {
+	if freq == 0 {
+		freq = defaultCarrierFrequency
+	}
+	if sampleRate == 0 {
+		sampleRate = defaultWAVSampleRate
+	}
+	if freq < 0 || sampleRate <= 0 || freq >= sampleRate {
+		err = fmt.Errorf("carrier frequency %d is not supported with sample rate %d", freq, sampleRate)
+		return
+	}
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+
+	total := 0
+	for _, d := range pulses {
+		total += d
+	}
+	samples := int(int64(total) * int64(sampleRate) / 1000000)
+	data := make([]byte, 0, samples*4)
+	var sample [4]byte
+	t, i := 0, 0 // time in microseconds at the end of pulses[i]
+	if len(pulses) > 0 {
+		t = pulses[0]
+	}
+	for n := 0; n < samples; n++ {
+		now := int(int64(n) * 1000000 / int64(sampleRate))
+		for i < len(pulses)-1 && now >= t {
+			i++
+			t += pulses[i]
+		}
+		v := int16(0)
+		if i%2 == 0 {
+			v = int16(math.Sin(2*math.Pi*float64(freq)/2*float64(n)/float64(sampleRate)) * math.MaxInt16)
+		}
+		binary.LittleEndian.PutUint16(sample[0:], uint16(v))
+		binary.LittleEndian.PutUint16(sample[2:], uint16(-v))
+		data = append(data, sample[:]...)
+	}
+
+	// RIFF header of PCM format
+	header := make([]byte, 44)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(36+len(data)))
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)                   // fmt chunk size
+	binary.LittleEndian.PutUint16(header[20:], 1)                    // PCM
+	binary.LittleEndian.PutUint16(header[22:], 2)                    // channels
+	binary.LittleEndian.PutUint32(header[24:], uint32(sampleRate))   // sample rate
+	binary.LittleEndian.PutUint32(header[28:], uint32(sampleRate*4)) // byte rate
+	binary.LittleEndian.PutUint16(header[32:], 4)                    // block align
+	binary.LittleEndian.PutUint16(header[34:], 16)                   // bits per sample
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], uint32(len(data)))
+
+	if _, err = w.Write(header); err != nil {
+		return
+	}
+	_, err = w.Write(data)
+	return
+}
@@ -0,0 +1,57 @@
+package broadlink
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestRenderWaveform(t *testing.T) {
+
+	code := EncodeCode([]int{1000, 500, 500, 20000, 1000, 500, 500, 20000})
+
+	s, err := FormatASCIIWaveform(code, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	if len(lines) != 2 || !strings.HasPrefix(lines[0], "##########_____#####__________ ") {
+		t.Errorf("unexpected timeline:\n%s", s)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteSVGWaveform(&buf, code, 0); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	if !strings.HasPrefix(svg, "<svg") || strings.Count(svg, "<path") != 2 || strings.Count(svg, "text-anchor=\"middle\"") != 6 || !strings.Contains(svg, "frame 2, gap ") {
+		t.Errorf("unexpected SVG:\n%s", svg)
+	}
+
+	buf.Reset()
+	if err = WriteWAV(&buf, code, 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	wav := buf.Bytes()
+	if string(wav[0:4]) != "RIFF" || string(wav[8:12]) != "WAVE" || binary.LittleEndian.Uint16(wav[22:]) != 2 {
+		t.Fatalf("unexpected WAV header % x", wav[:44])
+	}
+	data := wav[44:]
+	if len(data) != int(binary.LittleEndian.Uint32(wav[40:])) {
+		t.Errorf("unexpected data size")
+	}
+	// channels are inverted during a mark and silent during a space
+	left, right := int16(binary.LittleEndian.Uint16(data[4*10:])), int16(binary.LittleEndian.Uint16(data[4*10+2:]))
+	if left == 0 || left != -right {
+		t.Errorf("unexpected mark samples %d %d", left, right)
+	}
+	n := 48000 * 1200 / 1000000 // in the first space
+	if v := binary.LittleEndian.Uint32(data[4*n:]); v != 0 {
+		t.Errorf("unexpected space sample %08x", v)
+	}
+
+	if err = WriteWAV(&buf, code, 50000, 48000); err == nil {
+		t.Errorf("carrier above the sample rate is accepted")
+	}
+}
@@ -0,0 +1,219 @@
+package broadlink
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A command of a fixed-code OOK RF remote, as sent by 433MHz and 315MHz remote outlets and door bells.
+//
+//	"PT2262" (alias "Princeton") 12 tri-state bits, usually 8 address and 4 data bits. A bit is 4 units: a short pulse of 1 unit and a long pulse of 3 units
+//	"EV1527" 20-bit address and 4-bit data with the same waveform as PT2262
+//	"HT12E"  8-bit address and 4-bit data. A bit is 3 units of a low and a high: a low of 2 units for one and 1 unit for zero, and a high for the rest
+type RFCommand struct {
+	Protocol string // RF protocol name
+	Address  string // address bits in transmission order. '0', '1' or 'F' for a floating tri-state bit of PT2262
+	Data     string // data (button) bits in transmission order
+	Unit     int    // base pulse duration in microseconds. Zero for the protocol default when encoding
+}
+
+const (
+	pt2262DefaultUnit = 350
+	ht12eDefaultUnit  = 333
+	rfMinGap          = 5000 // minimum gap between frames in microseconds
+)
+
+// Encode an RF command into a code for SendRemoteControlCode() with REMOTE_RF433Mhz or REMOTE_RF315Mhz.
+// repeats is the number of frames following the first frame. Receivers usually need a few frames to accept a command.
+func EncodeRFCode(c RFCommand, repeats int) (code []byte, err error) {
+	bits := c.Address + c.Data
+	unit := c.Unit
+
+	var b pulseBuilder
+	var frame func()
+	switch name := strings.ToUpper(c.Protocol); name {
+	case "PT2262", "PRINCETON", "EV1527":
+		tristate, n := name != "EV1527", 24
+		if tristate {
+			n = 12
+		}
+		if unit == 0 {
+			unit = pt2262DefaultUnit
+		}
+		if len(bits) != n {
+			err = fmt.Errorf("%s needs %d address and data bits", c.Protocol, n)
+			return
+		}
+		// PT2262 tri-state bits are pairs of binary bits
+		var wave []bool
+		for _, ch := range bits {
+			switch {
+			case ch == '0':
+				wave = append(wave, false)
+				if tristate {
+					wave = append(wave, false)
+				}
+			case ch == '1':
+				wave = append(wave, true)
+				if tristate {
+					wave = append(wave, true)
+				}
+			case (ch == 'F' || ch == 'f') && tristate:
+				wave = append(wave, false, true)
+			default:
+				err = fmt.Errorf("invalid bit %q", ch)
+				return
+			}
+		}
+		frame = func() {
+			for _, one := range wave {
+				if one {
+					b.mark(unit * 3)
+					b.space(unit)
+				} else {
+					b.mark(unit)
+					b.space(unit * 3)
+				}
+			}
+			b.mark(unit) // sync
+			b.space(unit * 31)
+		}
+
+	case "HT12E":
+		if unit == 0 {
+			unit = ht12eDefaultUnit
+		}
+		if len(bits) != 12 || strings.Trim(bits, "01") != "" {
+			err = fmt.Errorf("HT12E needs 12 binary address and data bits")
+			return
+		}
+		frame = func() {
+			b.mark(unit) // sync
+			for _, ch := range bits {
+				if ch == '1' {
+					b.space(unit * 2)
+					b.mark(unit)
+				} else {
+					b.space(unit)
+					b.mark(unit * 2)
+				}
+			}
+			b.space(unit * 36) // pilot
+		}
+
+	default:
+		err = fmt.Errorf("unknown RF protocol %s", c.Protocol)
+		return
+	}
+
+	for i := 0; i <= repeats; i++ {
+		frame()
+	}
+	code = EncodeCode(b.pulses)
+	return
+}
+
+// Decode a code captured from a fixed-code RF remote.
+// A 24-bit PT2262/EV1527 frame whose bit pairs are all valid tri-state bits is reported as PT2262, otherwise as EV1527.
+func DecodeRFCode(code []byte) (c RFCommand, err error) {
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	for _, f := range SplitFrames(pulses, rfMinGap) {
+		var ok bool
+		switch len(f) {
+		case 50:
+			c, ok = decodePT2262Frame(f)
+		case 26:
+			c, ok = decodeHT12EFrame(f)
+		}
+		if ok {
+			return
+		}
+	}
+	err = fmt.Errorf("unknown RF code")
+	return
+}
+
+// check a short and a long pulse of a bit
+func rfPulsesOK(short, long, unit int, ratio float64) bool {
+	return matchRatio(short, unit, 0.4) && matchRatio(long, int(float64(unit)*ratio), 0.3)
+}
+
+// check a duration is within a relative tolerance
+func matchRatio(measured, expected int, tol float64) bool {
+	d := float64(measured - expected)
+	if d < 0 {
+		d = -d
+	}
+	return d <= float64(expected)*tol
+}
+
+// decode a PT2262 or EV1527 frame: 24 bits, a sync mark and a gap
+func decodePT2262Frame(f []int) (c RFCommand, ok bool) {
+	total := 0
+	for _, d := range f[:48] {
+		total += d
+	}
+	unit := total / 96 // 4 units per bit
+	if !matchRatio(f[48], unit, 0.4) || f[49] < unit*20 {
+		return
+	}
+	var bin []byte
+	for i := 0; i < 48; i += 2 {
+		mark, space := f[i], f[i+1]
+		switch {
+		case rfPulsesOK(mark, space, unit, 3):
+			bin = append(bin, '0')
+		case rfPulsesOK(space, mark, unit, 3):
+			bin = append(bin, '1')
+		default:
+			return
+		}
+	}
+
+	// tri-state bits
+	var tri []byte
+	for i := 0; i < 24; i += 2 {
+		switch string(bin[i : i+2]) {
+		case "00":
+			tri = append(tri, '0')
+		case "11":
+			tri = append(tri, '1')
+		case "01":
+			tri = append(tri, 'F')
+		default:
+			c = RFCommand{Protocol: "EV1527", Address: string(bin[:20]), Data: string(bin[20:]), Unit: unit}
+			return c, true
+		}
+	}
+	c = RFCommand{Protocol: "PT2262", Address: string(tri[:8]), Data: string(tri[8:]), Unit: unit}
+	return c, true
+}
+
+// decode a HT12E frame: a sync mark, 12 bits of a low and a high, and the pilot gap
+func decodeHT12EFrame(f []int) (c RFCommand, ok bool) {
+	total := 0
+	for _, d := range f[1:25] {
+		total += d
+	}
+	unit := total / 36 // 3 units per bit
+	if !matchRatio(f[0], unit, 0.4) || f[25] < unit*20 {
+		return
+	}
+	var bin []byte
+	for i := 1; i < 25; i += 2 {
+		low, high := f[i], f[i+1]
+		switch {
+		case rfPulsesOK(high, low, unit, 2):
+			bin = append(bin, '1')
+		case rfPulsesOK(low, high, unit, 2):
+			bin = append(bin, '0')
+		default:
+			return
+		}
+	}
+	c = RFCommand{Protocol: "HT12E", Address: string(bin[:8]), Data: string(bin[8:]), Unit: unit}
+	return c, true
+}
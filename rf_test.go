@@ -0,0 +1,77 @@
+package broadlink
+
+import (
+	"testing"
+)
+
+func TestRFCode(t *testing.T) {
+
+	tests := []struct {
+		in      RFCommand
+		decoded RFCommand
+	}{
+		{RFCommand{Protocol: "PT2262", Address: "0F0F0FFF", Data: "0001"}, RFCommand{Protocol: "PT2262", Address: "0F0F0FFF", Data: "0001"}},
+		{RFCommand{Protocol: "Princeton", Address: "11110000", Data: "F0F0"}, RFCommand{Protocol: "PT2262", Address: "11110000", Data: "F0F0"}},
+		{RFCommand{Protocol: "EV1527", Address: "10110010100111000101", Data: "1000"}, RFCommand{Protocol: "EV1527", Address: "10110010100111000101", Data: "1000"}},
+		{RFCommand{Protocol: "HT12E", Address: "10100101", Data: "0011", Unit: 400}, RFCommand{Protocol: "HT12E", Address: "10100101", Data: "0011"}},
+	}
+
+	for _, tc := range tests {
+		code, err := EncodeRFCode(tc.in, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulses, err := DecodeCode(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// start in the middle of a frame and add some jitter like a captured code
+		pulses = pulses[8:]
+		for i := range pulses {
+			if i%2 == 0 {
+				pulses[i] += 60
+			} else {
+				pulses[i] -= 60
+			}
+		}
+
+		c, err := DecodeRFCode(EncodeCode(pulses))
+		if err != nil {
+			t.Fatalf("%v: %v", tc.in, err)
+		}
+		unit := tc.in.Unit
+		if unit == 0 {
+			unit = pt2262DefaultUnit
+		}
+		if !matchRatio(c.Unit, unit, 0.1) {
+			t.Errorf("%v: unexpected unit %d", tc.in, c.Unit)
+		}
+		c.Unit = 0
+		if c != tc.decoded {
+			t.Errorf("%v: decoded to %v", tc.in, c)
+		}
+	}
+
+	// a HT12E frame following the waveform of the datasheet, as captured with a clock of about 3kHz:
+	// a sync high, then address A0..A7 = 01101100 and data D8..D11 = 1010, where a bit is a low and a high of 1/3 and 2/3 of the bit for zero, and 2/3 and 1/3 for one
+	ht12e := []int{
+		345,                                    // sync
+		318, 690, 672, 351, 659, 337, 330, 682, // A0..A3 = 0110
+		681, 328, 649, 344, 322, 667, 341, 676, // A4..A7 = 1100
+		667, 352, 336, 671, 690, 318, 325, 686, // D8..D11 = 1010
+		12010, // pilot
+	}
+	ht12e = append(ht12e, ht12e...)
+	c, err := DecodeRFCode(EncodeCode(ht12e))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Protocol != "HT12E" || c.Address != "01101100" || c.Data != "1010" || !matchRatio(c.Unit, 333, 0.1) {
+		t.Errorf("HT12E frame decoded to %v", c)
+	}
+
+	if _, err := EncodeRFCode(RFCommand{Protocol: "EV1527", Address: "0F0F0FFF", Data: "0001"}, 0); err == nil {
+		t.Errorf("invalid bits are accepted")
+	}
+}
@@ -0,0 +1,181 @@
+package broadlink
+
+import (
+	"context"
+	"fmt"
+)
+
+// A named remote of learned buttons.
+type Remote struct {
+	Name    string   `json:"name"`
+	Device  string   `json:"device,omitempty"` // MAC address of the assigned device, e.g. "34:ea:34:01:02:03". See Library.Send()
+	Notes   string   `json:"notes,omitempty"`
+	Buttons []Button `json:"buttons"`
+}
+
+// A learned button of a remote.
+type Button struct {
+	Name   string     `json:"name"`             // button name, e.g. "Power"
+	Type   RemoteType `json:"type"`             // signal type of the code
+	Code   []byte     `json:"code"`             // code for SendRemoteControlCode()
+	Repeat int        `json:"repeat,omitempty"` // default repeat count: 0 for once, 1 for twice, ...
+	Notes  string     `json:"notes,omitempty"`
+}
+
+var (
+	// Button lists of common remotes for NewTemplateSession(). Add entries to extend the templates.
+	RemoteTemplates = map[string][]string{
+		"TV": {
+			"Power", "Input", "Menu", "Up", "Down", "Left", "Right", "OK", "Back", "Home",
+			"Volume Up", "Volume Down", "Mute", "Channel Up", "Channel Down",
+			"0", "1", "2", "3", "4", "5", "6", "7", "8", "9",
+		},
+		"AV receiver": {
+			"Power", "Volume Up", "Volume Down", "Mute",
+			"Input HDMI1", "Input HDMI2", "Input HDMI3", "Input Optical", "Input Bluetooth",
+			"Sound Mode", "Menu", "Up", "Down", "Left", "Right", "OK", "Back",
+		},
+		"AC": {
+			"Power", "Mode", "Temperature Up", "Temperature Down", "Fan Speed", "Swing", "Turbo", "Sleep", "Timer",
+		},
+	}
+)
+
+// Action chosen by the prompt callback of a learning session.
+type LearnAction int
+
+const (
+	LEARN_CAPTURE LearnAction = iota // capture the button
+	LEARN_SKIP                       // leave the button unlearned and go to the next one
+	LEARN_UNDO                       // discard the previous button and prompt for it again
+	LEARN_REDO                       // discard the previous button and capture it again right away
+	LEARN_ACCEPT                     // keep a capture reported as a duplicate
+	LEARN_STOP                       // end the session with the buttons learned so far
+)
+
+// A prompt of a learning session.
+type LearnPrompt struct {
+	Index     int    // index of the button in the session
+	Total     int    // number of buttons in the session
+	Button    string // name of the button to press
+	Duplicate string // if not empty, the last capture matched this already learned button. Capture again, or accept it with LEARN_ACCEPT
+}
+
+// A guided session to learn the buttons of a remote in order.
+type LearnSession struct {
+	Name    string   // remote name
+	Buttons []string // button names in learning order
+
+	// Called before each capture. Returns the action to take. If nil, every button is captured once and duplicates are kept.
+	Prompt func(p LearnPrompt) LearnAction
+
+	Match  MatchOptions   // options to detect duplicates. See CompareCodes()
+	Verify *VerifyOptions // if not nil, each button is learned with LearnVerifiedCode()
+}
+
+// Make a learning session of a button list.
+func NewLearnSession(name string, buttons []string) *LearnSession {
+	return &LearnSession{Name: name, Buttons: append([]string{}, buttons...)}
+}
+
+// Make a learning session of a template of RemoteTemplates such as "TV", "AV receiver" or "AC".
+func NewTemplateSession(name, template string) (s *LearnSession, err error) {
+	buttons, ok := RemoteTemplates[template]
+	if !ok {
+		err = fmt.Errorf("unknown remote template %s", template)
+		return
+	}
+	s = NewLearnSession(name, buttons)
+	return
+}
+
+// Run the session with a device. Skipped buttons are not in the remote.
+// If ctx is done or learning fails, the buttons learned so far are returned with the error.
+func (s *LearnSession) Run(ctx context.Context, d *Device) (rm Remote, err error) {
+	learn := d.LearnCode
+	if s.Verify != nil {
+		learn = func(ctx context.Context) (rtype RemoteType, code []byte, err error) {
+			vc, err := d.LearnVerifiedCode(ctx, *s.Verify)
+			return vc.Type, vc.Code, err
+		}
+	}
+	return s.run(ctx, learn)
+}
+
+// run the session with a learning function
+func (s *LearnSession) run(ctx context.Context, learn func(ctx context.Context) (RemoteType, []byte, error)) (rm Remote, err error) {
+	n := len(s.Buttons)
+	results := make([]*Button, n) // learned buttons. nil for skipped ones
+	build := func() Remote {
+		rm := Remote{Name: s.Name}
+		for _, b := range results {
+			if b != nil {
+				rm.Buttons = append(rm.Buttons, *b)
+			}
+		}
+		return rm
+	}
+
+	i := 0
+	immediate := false // capture without a prompt
+	dup, pending := "", (*Button)(nil)
+	for i < n {
+		action := LEARN_CAPTURE
+		if !immediate && s.Prompt != nil {
+			action = s.Prompt(LearnPrompt{Index: i, Total: n, Button: s.Buttons[i], Duplicate: dup})
+		}
+		immediate = false
+
+		switch action {
+		case LEARN_SKIP:
+			results[i] = nil
+			i++
+		case LEARN_UNDO, LEARN_REDO:
+			if i > 0 {
+				i--
+				results[i] = nil
+				immediate = action == LEARN_REDO
+			}
+		case LEARN_ACCEPT:
+			if pending != nil {
+				results[i] = pending
+				i++
+			}
+		case LEARN_STOP:
+			rm = build()
+			return
+		case LEARN_CAPTURE:
+			rtype, code, e := learn(ctx)
+			if e != nil {
+				rm, err = build(), e
+				return
+			}
+			b := &Button{Name: s.Buttons[i], Type: rtype, Code: code}
+			if name := s.duplicateOf(results[:i], b); name != "" && s.Prompt != nil {
+				dup, pending = name, b
+				continue
+			}
+			results[i] = b
+			i++
+		default:
+			rm, err = build(), fmt.Errorf("unknown learn action %d", action)
+			return
+		}
+		dup, pending = "", nil
+	}
+	rm = build()
+	return
+}
+
+// find an already learned button with the same code
+func (s *LearnSession) duplicateOf(learned []*Button, b *Button) string {
+	for _, l := range learned {
+		if l == nil || l.Type != b.Type {
+			continue
+		}
+		if _, same, err := CompareCodes(l.Code, b.Code, s.Match); err == nil && same {
+			return l.Name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package broadlink
+
+import (
+	"context"
+	"testing"
+)
+
+func TestLearnSession(t *testing.T) {
+
+	code := func(command uint32) []byte {
+		c, err := EncodeIRCommand(IRCommand{Protocol: "NEC", Address: 4, Command: command}, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// captures in order of presses
+	presses := [][]byte{
+		code(1), // Power
+		code(1), // Volume Up, pressed Power again by mistake
+		code(2), // Volume Up
+		code(9), // Volume Down, wrong button
+		code(3), // Volume Down, redo
+		code(4), // Mute
+	}
+	n := 0
+	learn := func(ctx context.Context) (RemoteType, []byte, error) {
+		n++
+		return REMOTE_IR, presses[n-1], nil
+	}
+
+	s := NewLearnSession("tv", []string{"Power", "Volume Up", "Volume Down", "Input", "Mute"})
+	var prompts []LearnPrompt
+	redone := false
+	s.Prompt = func(p LearnPrompt) LearnAction {
+		prompts = append(prompts, p)
+		switch {
+		case p.Button == "Input":
+			if !redone {
+				redone = true
+				return LEARN_REDO // Volume Down was wrong
+			}
+			return LEARN_SKIP
+		}
+		return LEARN_CAPTURE
+	}
+
+	rm, err := s.run(context.Background(), learn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(presses) {
+		t.Errorf("unexpected capture count %d", n)
+	}
+	want := map[string][]byte{"Power": code(1), "Volume Up": code(2), "Volume Down": code(3), "Mute": code(4)}
+	if rm.Name != "tv" || len(rm.Buttons) != len(want) {
+		t.Fatalf("unexpected remote %+v", rm)
+	}
+	for _, b := range rm.Buttons {
+		if string(b.Code) != string(want[b.Name]) || b.Type != REMOTE_IR {
+			t.Errorf("unexpected code of %s", b.Name)
+		}
+	}
+	dups := 0
+	for _, p := range prompts {
+		if p.Duplicate != "" {
+			dups++
+			if p.Duplicate != "Power" || p.Button != "Volume Up" {
+				t.Errorf("unexpected duplicate prompt %+v", p)
+			}
+		}
+	}
+	if dups != 1 {
+		t.Errorf("duplicate reported %d times", dups)
+	}
+
+	// undo and accepting a duplicate
+	n = 0
+	presses = [][]byte{code(1), code(2), code(3), code(3)}
+	s = NewLearnSession("tv", []string{"A", "B", "C"})
+	undone := false
+	s.Prompt = func(p LearnPrompt) LearnAction {
+		switch {
+		case p.Duplicate != "":
+			return LEARN_ACCEPT
+		case p.Button == "C" && !undone:
+			undone = true
+			return LEARN_UNDO
+		}
+		return LEARN_CAPTURE
+	}
+	rm, err = s.run(context.Background(), learn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rm.Buttons) != 3 || string(rm.Buttons[1].Code) != string(code(3)) || string(rm.Buttons[2].Code) != string(code(3)) {
+		t.Errorf("unexpected remote %+v", rm)
+	}
+
+	if _, err = NewTemplateSession("tv", "TV"); err != nil {
+		t.Error(err)
+	}
+	if _, err = NewTemplateSession("tv", "Toaster"); err == nil {
+		t.Errorf("unknown template is accepted")
+	}
+}
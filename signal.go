@@ -0,0 +1,182 @@
+package broadlink
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// Duration of a BroadLink code tick in nanoseconds (32.84µs).
+	codeTickNs = 32840
+
+	// Trailing gap value that terminates a BroadLink code.
+	codeTerminator = 0x0d05
+)
+
+// A remote control signal decoded from a BroadLink code.
+// BroadLink codes carry no carrier information. Frequency and DutyCycle are set by converters from formats that have them.
+type Signal struct {
+	Type      RemoteType // signal type. REMOTE_IR, REMOTE_RF433Mhz or REMOTE_RF315Mhz
+	Repeat    int        // repeat count stored in the code header. 0 for once, 1 for twice, ...
+	Pulses    []int      // alternating mark and space durations in microseconds, starting with a mark
+	Frequency int        // IR carrier frequency in Hz. zero if unknown
+	DutyCycle float64    // IR carrier duty cycle from 0 to 1. zero if unknown
+}
+
+// Convert a duration in microseconds to BroadLink ticks.
+func usToTicks(us int) int {
+	return (us*1000 + codeTickNs/2) / codeTickNs
+}
+
+// Convert BroadLink ticks to a duration in microseconds.
+func ticksToUs(ticks int) int {
+	return (ticks*codeTickNs + 500) / 1000
+}
+
+// Decode a code returned by ReadCapturedRemoteControlCode() into alternating mark and space durations in microseconds.
+// Each duration is a single byte tick count, or a zero byte followed by a big-endian 16-bit tick count for long values.
+// The 0x0d05 terminator is decoded as the trailing gap. Zero padding after the last duration is ignored.
+func DecodeCode(code []byte) (pulses []int, err error) {
+	pulses = make([]int, 0, len(code))
+	for i := 0; i < len(code); i++ {
+		ticks := int(code[i])
+		if ticks == 0 {
+			if i+2 >= len(code) {
+				// zero padding at the end of the code
+				break
+			}
+			ticks = int(binary.BigEndian.Uint16(code[i+1:]))
+			i += 2
+			if ticks == 0 {
+				// zero padding
+				break
+			}
+		}
+		pulses = append(pulses, ticksToUs(ticks))
+	}
+	if len(pulses) == 0 {
+		err = fmt.Errorf("empty remote control code")
+		pulses = nil
+	}
+	return
+}
+
+// Encode alternating mark and space durations in microseconds into a code for SendRemoteControlCode().
+// If pulses ends with a mark, the 0x0d05 terminator is appended as the trailing gap.
+func EncodeCode(pulses []int) (code []byte) {
+	code = make([]byte, 0, len(pulses)+3)
+	put := func(ticks int) {
+		if ticks < 1 {
+			ticks = 1
+		} else if ticks > 0xffff {
+			ticks = 0xffff
+		}
+		if ticks < 0x100 {
+			code = append(code, byte(ticks))
+		} else {
+			code = append(code, 0x00, byte(ticks>>8), byte(ticks))
+		}
+	}
+	for _, p := range pulses {
+		put(usToTicks(p))
+	}
+	if len(pulses)%2 == 1 {
+		put(codeTerminator)
+	}
+	return
+}
+
+// Decode a bare code returned by ReadCapturedRemoteControlCode() into a Signal.
+func NewSignal(rtype RemoteType, code []byte) (s *Signal, err error) {
+	pulses, err := DecodeCode(code)
+	if err != nil {
+		return
+	}
+	s = &Signal{Type: rtype, Pulses: pulses}
+	return
+}
+
+// Decode a BroadLink code with its header into a Signal.
+// The header consists of the signal type byte, the repeat byte and a little-endian 16-bit code length.
+func DecodeSignal(data []byte) (s *Signal, err error) {
+	if len(data) < 4 {
+		err = fmt.Errorf("incomplete data")
+		return
+	}
+	sz := int(binary.LittleEndian.Uint16(data[2:4]))
+	if len(data) < 4+sz {
+		err = fmt.Errorf("incomplete data")
+		return
+	}
+	s, err = NewSignal(RemoteType(data[0]), data[4:4+sz])
+	if err != nil {
+		return
+	}
+	s.Repeat = int(data[1])
+	return
+}
+
+// Get the bare code of the signal, which can be passed to SendRemoteControlCode().
+func (s *Signal) Code() []byte {
+	return EncodeCode(s.Pulses)
+}
+
+// Encode the signal into a BroadLink code with the type, repeat and length header.
+func (s *Signal) Encode() []byte {
+	return codePacket(s.Type, s.Repeat, s.Code())
+}
+
+// Build a code packet with the type, repeat and length header.
+func codePacket(rtype RemoteType, repeat int, code []byte) []byte {
+	data := make([]byte, 4+len(code))
+	data[0] = byte(rtype)
+	data[1] = byte(repeat)
+	binary.LittleEndian.PutUint16(data[2:], uint16(len(code)))
+	copy(data[4:], code)
+	return data
+}
+
+// Builder of alternating mark and space durations. Consecutive marks or spaces are merged.
+type pulseBuilder struct {
+	pulses []int
+}
+
+// Append a duration in microseconds. A positive value is a mark and a negative value is a space.
+// Leading spaces are dropped since a signal always starts with a mark.
+func (b *pulseBuilder) add(d int) {
+	if d == 0 {
+		return
+	}
+	n := len(b.pulses)
+	isMark := d > 0
+	if !isMark {
+		d = -d
+		if n == 0 {
+			return
+		}
+	}
+	if n > 0 && (n%2 == 1) == isMark {
+		// same kind with the last duration
+		b.pulses[n-1] += d
+		return
+	}
+	b.pulses = append(b.pulses, d)
+}
+
+// Append a mark of d microseconds.
+func (b *pulseBuilder) mark(d int) {
+	b.add(d)
+}
+
+// Append a space of d microseconds.
+func (b *pulseBuilder) space(d int) {
+	b.add(-d)
+}
+
+// Total duration of the pulses in microseconds.
+func (b *pulseBuilder) duration() (total int) {
+	for _, d := range b.pulses {
+		total += d
+	}
+	return
+}
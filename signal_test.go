@@ -0,0 +1,63 @@
+package broadlink
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestSignalCode(t *testing.T) {
+
+	// a captured code: 9ms/4.5ms header, some bits and the 0x0d05 terminator, followed by zero padding
+	code := []byte{
+		0x00, 0x01, 0x12, 0x89, 0x11, 0x11, 0x11, 0x33, 0x12, 0x11,
+		0x11, 0x33, 0x11, 0x00, 0x05, 0x9c, 0x11, 0x00, 0x0d, 0x05,
+	}
+	padded := append(append([]byte{}, code...), 0x00, 0x00, 0x00, 0x00)
+
+	pulses, err := DecodeCode(padded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pulses) != 14 {
+		t.Fatalf("unexpected pulse count %d", len(pulses))
+	}
+	if pulses[0] != 8998 || pulses[1] != 4499 {
+		t.Errorf("invalid header timings %d, %d", pulses[0], pulses[1])
+	}
+	if pulses[13] != ticksToUs(codeTerminator) {
+		t.Errorf("invalid trailing gap %d", pulses[13])
+	}
+
+	encoded := EncodeCode(pulses)
+	if !bytes.Equal(encoded, code) {
+		t.Fatalf("round trip failed:\n%x\n%x", code, encoded)
+	}
+
+	// a mark at the end gets the terminator
+	encoded = EncodeCode(pulses[:13])
+	if !bytes.Equal(encoded, code) {
+		t.Fatalf("terminator not appended:\n%x\n%x", code, encoded)
+	}
+
+	// header round trip
+	s, err := NewSignal(REMOTE_IR, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Repeat = 2
+	data := s.Encode()
+	if data[0] != 0x26 || data[1] != 2 || int(data[2]) != len(code) || data[3] != 0 {
+		t.Fatalf("invalid header %x", data[:4])
+	}
+	s2, err := DecodeSignal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.Type != REMOTE_IR || s2.Repeat != 2 || !bytes.Equal(s2.Code(), code) {
+		t.Fatal("signal round trip failed")
+	}
+
+	if _, err = DecodeCode([]byte{0x00, 0x00, 0x00}); err == nil {
+		t.Error("empty code accepted")
+	}
+}
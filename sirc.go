@@ -0,0 +1,91 @@
+package broadlink
+
+import (
+	"fmt"
+)
+
+// Sony SIRC protocols. Pulse width encoded, least significant bit first.
+//
+//	"SIRC"   7-bit command, 5-bit address
+//	"SIRC15" 7-bit command, 8-bit address
+//	"SIRC20" 7-bit command, 5-bit address and 8-bit extended address. Address = address | extended<<5
+//
+// Sony devices usually expect at least three frames, so pass repeats of 2 or more.
+
+const (
+	sircUnit   = 600   // microseconds
+	sircPeriod = 45000 // frame period
+)
+
+var sircAddressBits = map[string]int{"SIRC": 5, "SIRC15": 8, "SIRC20": 13}
+
+func init() {
+	registerIRProtocol(&irProtocol{name: "SIRC", frequency: 40000, encode: encodeSIRC, decode: decodeSIRC})
+	registerIRProtocol(&irProtocol{name: "SIRC15", frequency: 40000, encode: encodeSIRC})
+	registerIRProtocol(&irProtocol{name: "SIRC20", frequency: 40000, encode: encodeSIRC})
+}
+
+func encodeSIRC(c IRCommand, repeats int) (pulses []int, err error) {
+	abits := sircAddressBits[c.Protocol]
+	if c.Command > 0x7f || c.Address >= 1<<uint(abits) {
+		err = fmt.Errorf("%s command must be 7 bits and address %d bits", c.Protocol, abits)
+		return
+	}
+	data := uint64(c.Command) | uint64(c.Address)<<7
+	nbits := 7 + abits
+
+	var b pulseBuilder
+	for f := 0; f <= repeats; f++ {
+		start := b.duration()
+		b.mark(sircUnit * 4)
+		for i := 0; i < nbits; i++ {
+			b.space(sircUnit)
+			if data>>uint(i)&1 == 1 {
+				b.mark(sircUnit * 2)
+			} else {
+				b.mark(sircUnit)
+			}
+		}
+		padFrame(&b, start, sircPeriod, sircUnit*10)
+	}
+	pulses = b.pulses
+	return
+}
+
+func decodeSIRC(pulses []int) (c IRCommand, ok bool) {
+	if len(pulses) < 2 || !matchDuration(pulses[0], sircUnit*4) {
+		return
+	}
+	var data uint64
+	nbits := 0
+	for i := 1; i+1 < len(pulses); i += 2 {
+		if !matchDuration(pulses[i], sircUnit) {
+			if nbits > 0 && pulses[i] > sircUnit*4 {
+				break // end of frame
+			}
+			return
+		}
+		switch {
+		case matchDuration(pulses[i+1], sircUnit*2):
+			data |= 1 << uint(nbits)
+		case matchDuration(pulses[i+1], sircUnit):
+		default:
+			return
+		}
+		nbits++
+	}
+	switch nbits {
+	case 12:
+		c.Protocol = "SIRC"
+	case 15:
+		c.Protocol = "SIRC15"
+	case 20:
+		c.Protocol = "SIRC20"
+	default:
+		return
+	}
+	c.Command = uint32(data & 0x7f)
+	c.Address = uint32(data >> 7)
+	ok = true
+	return
+}
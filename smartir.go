@@ -0,0 +1,270 @@
+package broadlink
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A code file of the Home Assistant SmartIR project, for a climate device, a fan or a media player.
+type SmartIRFile struct {
+	Manufacturer        string   `json:"manufacturer"`
+	SupportedModels     []string `json:"supportedModels"`
+	SupportedController string   `json:"supportedController"` // "Broadlink" for BroadLink codes
+	CommandsEncoding    string   `json:"commandsEncoding"`    // "Base64", "Hex", "Pronto" or "Raw"
+
+	// climate devices
+	MinTemperature float64  `json:"minTemperature"`
+	MaxTemperature float64  `json:"maxTemperature"`
+	Precision      float64  `json:"precision"`
+	OperationModes []string `json:"operationModes"`
+	FanModes       []string `json:"fanModes"`
+	SwingModes     []string `json:"swingModes"`
+
+	// fans
+	Speed []string `json:"speed"`
+
+	// commands keyed by state, e.g. Commands.Children["cool"].Children["auto"].Children["24"] for climate devices
+	Commands SmartIRCommand `json:"commands"`
+}
+
+// A node of SmartIR commands. A leaf has a code, and others have named children.
+type SmartIRCommand struct {
+	Type     RemoteType // remote type of the code
+	Repeat   int        // repeat count stored in the code: 0 for once
+	Code     []byte     // code for SendRemoteControlCode(). nil for a node with children
+	Children map[string]*SmartIRCommand
+
+	raw       string // undecoded code string
+	rawPulses []int  // undecoded durations of a Raw code given as a JSON array
+}
+
+// A state of a climate device.
+type ClimateState struct {
+	Mode        string  // operation mode such as "cool", "heat" or "auto". "off" to turn the device off
+	Fan         string  // fan mode such as "low" or "auto"
+	Swing       string  // swing mode. Empty if the device has no swing modes
+	Temperature float64 // target temperature
+}
+
+// Unmarshal a code string, an array of Raw durations or an object of children.
+func (c *SmartIRCommand) UnmarshalJSON(data []byte) (err error) {
+	var s string
+	if json.Unmarshal(data, &s) == nil {
+		c.raw = s
+		return
+	}
+	var values []float64
+	if json.Unmarshal(data, &values) == nil {
+		c.rawPulses = make([]int, len(values))
+		for i, v := range values {
+			c.rawPulses[i] = int(math.Round(v))
+		}
+		return
+	}
+	var children map[string]*SmartIRCommand
+	if err = json.Unmarshal(data, &children); err != nil {
+		return
+	}
+	c.Children = children
+	return
+}
+
+// Load a SmartIR JSON code file. Codes are decoded from Base64 or Hex BroadLink codes, Pronto Hex codes, or Raw durations in microseconds.
+// Raw codes are arrays or comma separated strings of durations. Signed durations are marks if positive and spaces if negative, and unsigned ones alternate from a mark.
+func LoadSmartIR(r io.Reader) (f *SmartIRFile, err error) {
+	f = &SmartIRFile{}
+	if err = json.NewDecoder(r).Decode(f); err != nil {
+		f = nil
+		return
+	}
+	var decode func(c *SmartIRCommand) error
+	switch strings.ToLower(f.CommandsEncoding) {
+	case "base64", "":
+		decode = func(c *SmartIRCommand) (e error) {
+			c.Type, c.Repeat, c.Code, e = ParseCodeString(c.raw)
+			return
+		}
+	case "hex":
+		decode = func(c *SmartIRCommand) (e error) {
+			data, e := hex.DecodeString(strings.TrimSpace(c.raw))
+			if e != nil {
+				return fmt.Errorf("invalid hex code")
+			}
+			c.Type, c.Repeat, c.Code, e = parseCodePacket(data)
+			return
+		}
+	case "raw":
+		decode = func(c *SmartIRCommand) (e error) {
+			values := c.rawPulses
+			if values == nil {
+				if values, e = parseRawDurations(c.raw); e != nil {
+					return
+				}
+			}
+			c.Type = REMOTE_IR
+			c.Code, e = rawDurationsCode(values)
+			return
+		}
+	case "pronto":
+		decode = func(c *SmartIRCommand) (e error) {
+			c.Type = REMOTE_IR
+			c.Code, e = ProntoToCode(c.raw, 0)
+			return
+		}
+	default:
+		err = fmt.Errorf("unsupported commands encoding %s", f.CommandsEncoding)
+		f = nil
+		return
+	}
+	if f.Commands.Children == nil {
+		err = fmt.Errorf("no commands")
+		f = nil
+		return
+	}
+	if err = f.Commands.decode("", decode); err != nil {
+		f = nil
+	}
+	return
+}
+
+// decode codes of a command tree
+func (c *SmartIRCommand) decode(path string, decode func(c *SmartIRCommand) error) error {
+	if c.Children == nil {
+		if err := decode(c); err != nil {
+			return fmt.Errorf("command %s: %v", path, err)
+		}
+		c.raw, c.rawPulses = "", nil
+		return nil
+	}
+	for k, child := range c.Children {
+		if child == nil {
+			return fmt.Errorf("command %s/%s: null command", path, k)
+		}
+		if err := child.decode(path+"/"+k, decode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Look up a command by keys, e.g. Lookup("sources", "HDMI1") or Lookup("off"). Keys are case insensitive.
+func (f *SmartIRFile) Lookup(keys ...string) (cmd *SmartIRCommand, err error) {
+	cmd = &f.Commands
+	for i, k := range keys {
+		child := cmd.child(k)
+		if child == nil {
+			err = fmt.Errorf("command %s not found", strings.Join(keys[:i+1], "/"))
+			cmd = nil
+			return
+		}
+		cmd = child
+	}
+	if cmd.Code == nil {
+		err = fmt.Errorf("command %s has no code", strings.Join(keys, "/"))
+		cmd = nil
+	}
+	return
+}
+
+// Look up the command of a climate state, e.g. ClimateState{Mode: "cool", Fan: "auto", Temperature: 24}.
+// The temperature is rounded to the precision of the file, and must be within the temperature range.
+// Send the result with SendRemoteControlCode(cmd.Type, cmd.Code, cmd.Repeat+1).
+func (f *SmartIRFile) ClimateCommand(st ClimateState) (cmd *SmartIRCommand, err error) {
+	if strings.EqualFold(st.Mode, "off") {
+		return f.Lookup("off")
+	}
+
+	t := st.Temperature
+	if p := f.Precision; p > 0 {
+		t = math.Round(t/p) * p
+	}
+	if (f.MinTemperature != 0 || f.MaxTemperature != 0) && (t < f.MinTemperature || t > f.MaxTemperature) {
+		err = fmt.Errorf("temperature %g out of range %g-%g", st.Temperature, f.MinTemperature, f.MaxTemperature)
+		return
+	}
+
+	keys := []string{st.Mode, st.Fan}
+	if st.Swing != "" {
+		keys = append(keys, st.Swing)
+	}
+	keys = append(keys, strconv.FormatFloat(t, 'f', -1, 64))
+	cmd, err = f.Lookup(keys...)
+	if err != nil && math.Trunc(t) == t {
+		// some files have keys such as "24.0"
+		keys[len(keys)-1] = strconv.FormatFloat(t, 'f', 1, 64)
+		if c, e := f.Lookup(keys...); e == nil {
+			cmd, err = c, nil
+		}
+	}
+	return
+}
+
+// get a child by a case insensitive name
+func (c *SmartIRCommand) child(name string) *SmartIRCommand {
+	if child := c.Children[name]; child != nil {
+		return child
+	}
+	for k, child := range c.Children {
+		if strings.EqualFold(k, name) {
+			return child
+		}
+	}
+	return nil
+}
+
+// Names of the commands at keys, sorted. e.g. CommandNames("cool") for fan modes of cool mode.
+func (f *SmartIRFile) CommandNames(keys ...string) (names []string) {
+	cmd := &f.Commands
+	for _, k := range keys {
+		if cmd = cmd.child(k); cmd == nil {
+			return
+		}
+	}
+	for name := range cmd.Children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// parse a string of durations separated by commas or spaces
+func parseRawDurations(s string) (values []int, err error) {
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' }) {
+		v, e := strconv.Atoi(f)
+		if e != nil {
+			err = fmt.Errorf("invalid duration %q", f)
+			return
+		}
+		values = append(values, v)
+	}
+	return
+}
+
+// build a code of raw durations. Signed durations are marks and spaces by the sign, and unsigned ones alternate from a mark
+func rawDurationsCode(values []int) (code []byte, err error) {
+	signed := false
+	for _, v := range values {
+		if v < 0 {
+			signed = true
+		}
+	}
+	var b pulseBuilder
+	for i, v := range values {
+		if !signed && i%2 == 1 {
+			v = -v
+		}
+		b.add(v)
+	}
+	if len(b.pulses) == 0 {
+		err = fmt.Errorf("empty raw code")
+		return
+	}
+	code = EncodeCode(b.pulses)
+	return
+}
@@ -0,0 +1,100 @@
+package broadlink
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestSmartIR(t *testing.T) {
+
+	code := func(n int) string {
+		return FormatBase64Code(REMOTE_IR, 0, EncodeCode([]int{3000, 1000, 500 * n, 20000}))
+	}
+	file := fmt.Sprintf(`{
+  "manufacturer": "Test",
+  "supportedModels": ["AC-1"],
+  "supportedController": "Broadlink",
+  "commandsEncoding": "Base64",
+  "minTemperature": 18.0,
+  "maxTemperature": 30.0,
+  "precision": 1.0,
+  "operationModes": ["cool", "heat"],
+  "fanModes": ["low", "auto"],
+  "commands": {
+    "off": "%s",
+    "cool": {
+      "low": {"24": "%s", "25": "%s"},
+      "auto": {"24": "%s"}
+    },
+    "heat": {
+      "auto": {"24.0": "%s"}
+    }
+  }
+}`, code(1), code(2), code(3), code(4), code(5))
+
+	f, err := LoadSmartIR(strings.NewReader(file))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Manufacturer != "Test" || len(f.OperationModes) != 2 {
+		t.Errorf("unexpected file %+v", f)
+	}
+
+	tests := []struct {
+		st ClimateState
+		n  int
+	}{
+		{ClimateState{Mode: "off"}, 1},
+		{ClimateState{Mode: "cool", Fan: "low", Temperature: 24}, 2},
+		{ClimateState{Mode: "cool", Fan: "low", Temperature: 24.6}, 3},
+		{ClimateState{Mode: "Cool", Fan: "Auto", Temperature: 24}, 4},
+		{ClimateState{Mode: "heat", Fan: "auto", Temperature: 24}, 5},
+	}
+	for _, tc := range tests {
+		cmd, err := f.ClimateCommand(tc.st)
+		if err != nil {
+			t.Fatalf("%v: %v", tc.st, err)
+		}
+		pulses, err := DecodeCode(cmd.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cmd.Type != REMOTE_IR || !within(pulses[2], 500*tc.n) {
+			t.Errorf("%v: unexpected code %v", tc.st, pulses)
+		}
+	}
+
+	if _, err = f.ClimateCommand(ClimateState{Mode: "cool", Fan: "low", Temperature: 31}); err == nil {
+		t.Errorf("temperature out of range is accepted")
+	}
+	if _, err = f.ClimateCommand(ClimateState{Mode: "dry", Fan: "low", Temperature: 24}); err == nil {
+		t.Errorf("unknown mode is accepted")
+	}
+	if names := f.CommandNames("cool"); strings.Join(names, ",") != "auto,low" {
+		t.Errorf("unexpected command names %v", names)
+	}
+
+	// Hex and Raw encodings
+	for _, tc := range []struct{ encoding, code string }{
+		{"Hex", `"` + FormatHexCode(REMOTE_IR, 0, EncodeCode([]int{3000, 1000, 1500, 20000})) + `"`},
+		{"Raw", "[3000, -1000, 1500, -20000]"},
+		{"Raw", `"3000,1000,1500,20000"`},
+	} {
+		f, err := LoadSmartIR(strings.NewReader(`{"commandsEncoding": "` + tc.encoding + `", "commands": {"on": ` + tc.code + `}}`))
+		if err != nil {
+			t.Fatalf("%s %s: %v", tc.encoding, tc.code, err)
+		}
+		cmd, err := f.Lookup("on")
+		if err != nil {
+			t.Fatal(err)
+		}
+		pulses, err := DecodeCode(cmd.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cmd.Type != REMOTE_IR || len(pulses) != 4 || !within(pulses[0], 3000) || !within(pulses[2], 1500) {
+			t.Errorf("%s %s: unexpected code %v", tc.encoding, tc.code, pulses)
+		}
+	}
+}